@@ -12,14 +12,37 @@ var buildCmd = &cobra.Command{
 	Use:   "build",
 	Short: "Build agent package",
 	Long: `Build and validate your agent package, tool, chain, prompt, or dataset.
-This will compile, validate, and prepare your package for distribution.`,
+This will validate your package and pack it into a versioned archive
+(<output>/<name>-<version>.tgz) with a metadata file listing its contents
+and SHA-256 digests.
+
+Archives are reproducible: entries are sorted and their timestamps,
+ownership and permissions normalised, so building the same source yields
+the same digest on every machine. Use --verify-reproducible to build twice
+and fail if the archives differ.
+
+Files listed in .agenthubignore (gitignore syntax) are left out of the
+archive, as are .git, dist, agent_modules and secrets such as .env. Use
+--list-files to print exactly what would be packed.
+
+With --watch the package is rebuilt whenever the content of a file that
+goes into the archive changes, until interrupted with Ctrl+C.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("Building agent package...")
 		
 		verbose, _ := cmd.Flags().GetBool("verbose")
 		output, _ := cmd.Flags().GetString("output")
+		verifyReproducible, _ := cmd.Flags().GetBool("verify-reproducible")
+		watch, _ := cmd.Flags().GetBool("watch")
+		listFiles, _ := cmd.Flags().GetBool("list-files")
 		
-		return commands.BuildPackage(verbose, output)
+		return commands.BuildPackage(commands.BuildOptions{
+			Verbose:            verbose,
+			OutputDir:          output,
+			VerifyReproducible: verifyReproducible,
+			Watch:              watch,
+			ListFiles:          listFiles,
+		})
 	},
 }
 
@@ -27,5 +50,7 @@ func init() {
 	rootCmd.AddCommand(buildCmd)
 	buildCmd.Flags().Bool("verbose", false, "verbose build output")
 	buildCmd.Flags().StringP("output", "o", "dist", "output directory for built package")
+	buildCmd.Flags().Bool("verify-reproducible", false, "build twice and fail if the archives differ")
+	buildCmd.Flags().Bool("list-files", false, "print the files that would be packed and their total size")
 	buildCmd.Flags().BoolP("watch", "w", false, "watch for changes and rebuild")
 } 
\ No newline at end of file
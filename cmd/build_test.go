@@ -37,6 +37,16 @@ func TestBuildCommandFlags(t *testing.T) {
 	assert.NotNil(t, watchFlag, "Watch flag should exist")
 	assert.Equal(t, "bool", watchFlag.Value.Type())
 	assert.Equal(t, "w", watchFlag.Shorthand)
+	
+	// Test verify-reproducible flag
+	verifyFlag := cmd.Flags().Lookup("verify-reproducible")
+	assert.NotNil(t, verifyFlag, "Verify-reproducible flag should exist")
+	assert.Equal(t, "false", verifyFlag.DefValue)
+	
+	// Test list-files flag
+	listFlag := cmd.Flags().Lookup("list-files")
+	assert.NotNil(t, listFlag, "List-files flag should exist")
+	assert.Equal(t, "bool", listFlag.Value.Type())
 }
 
 func TestBuildCommandNoArgs(t *testing.T) {
@@ -12,7 +12,21 @@ var initCmd = &cobra.Command{
 	Use:   "init [project-name]",
 	Short: "Initialize a new AgentHub project",
 	Long: `Initialize a new AgentHub project with the default structure and configuration.
-This will create the necessary files and directories for an AgentHub project.`,
+This will create the necessary files and directories for an AgentHub project,
+a starter agentpkg.yaml filled in from --name, --version, --description,
+--author and --registry, and a .agenthubignore. A directory that is not
+empty is left alone unless --force is given.
+
+With --interactive the package kind, name, description, author, license,
+registry and initial dependencies are asked for on the terminal, with the
+flags as defaults. When stdin is not a terminal the flags are used as is.
+
+Use --template to scaffold the project from a built-in template (agent,
+tool, chain, prompt-library or dataset), which writes a manifest, example
+files and tests. Any other template name is looked up as a package in the
+registry (name[@version]) whose template/ directory is copied. Template
+files may refer to {{agenthub.name}} and {{agenthub.author}}, which are
+replaced by the project name and --author.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		projectName := "my-agent-project"
@@ -21,12 +35,35 @@ This will create the necessary files and directories for an AgentHub project.`,
 		}
 		
 		fmt.Printf("Initializing AgentHub project: %s\n", projectName)
-		return commands.InitProject(projectName)
+		name, _ := cmd.Flags().GetString("name")
+		version, _ := cmd.Flags().GetString("version")
+		description, _ := cmd.Flags().GetString("description")
+		author, _ := cmd.Flags().GetString("author")
+		template, _ := cmd.Flags().GetString("template")
+		force, _ := cmd.Flags().GetBool("force")
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		return commands.InitProject(projectName, commands.InitOptions{
+			Name:        name,
+			Version:     version,
+			Description: description,
+			Author:      author,
+			Registry:    registryLocation(cmd),
+			Template:    template,
+			Force:       force,
+			Interactive: interactive,
+			Network:     networkMode(),
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(initCmd)
-	initCmd.Flags().BoolP("template", "t", false, "use a template for initialization")
+	initCmd.Flags().StringP("template", "t", "", "scaffold the project from a built-in or registry template")
+	initCmd.Flags().String("name", "", "package name (default is the project directory name)")
+	initCmd.Flags().String("version", "", "initial package version (default 0.1.0)")
+	initCmd.Flags().String("description", "", "package description")
+	initCmd.Flags().String("author", "", "package author")
+	initCmd.Flags().BoolP("force", "f", false, "initialize a directory that is not empty")
+	initCmd.Flags().BoolP("interactive", "i", false, "answer questions to set up the project")
 	initCmd.Flags().StringP("registry", "r", "default", "specify the registry to use")
 } 
\ No newline at end of file
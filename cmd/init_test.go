@@ -24,7 +24,20 @@ func TestInitCommandFlags(t *testing.T) {
 	// Test that required flags exist
 	templateFlag := cmd.Flags().Lookup("template")
 	assert.NotNil(t, templateFlag, "Template flag should exist")
-	assert.Equal(t, "bool", templateFlag.Value.Type())
+	assert.Equal(t, "string", templateFlag.Value.Type())
+	assert.Equal(t, "t", templateFlag.Shorthand)
+	
+	for _, name := range []string{"name", "version", "description", "author"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "%s flag should exist", name)
+	}
+	
+	forceFlag := cmd.Flags().Lookup("force")
+	assert.NotNil(t, forceFlag, "Force flag should exist")
+	assert.Equal(t, "false", forceFlag.DefValue)
+	
+	interactiveFlag := cmd.Flags().Lookup("interactive")
+	assert.NotNil(t, interactiveFlag, "Interactive flag should exist")
+	assert.Equal(t, "i", interactiveFlag.Shorthand)
 	
 	registryFlag := cmd.Flags().Lookup("registry")
 	assert.NotNil(t, registryFlag, "Registry flag should exist")
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"agenthub/internal/commands"
 )
 
@@ -12,17 +13,48 @@ var installCmd = &cobra.Command{
 	Use:   "install [package-name]",
 	Short: "Install packages from the registry",
 	Long: `Install agent packages, tools, chains, prompts, or datasets from the AgentHub registry.
-If no package name is provided, it will install all dependencies from the project file.`,
+If no package name is provided, it will install all dependencies from the project file.
+A package name, optionally followed by @range, is added to the project's dependencies
+and installed; --version pins the version a range resolves to instead, and --dev
+adds it to devDependencies.
+
+Packages are unpacked once into a store shared by all projects, in the user cache
+directory unless the "store" config key names another, and linked into agent_modules.
+
+With --global, packages are installed into a per-user prefix, ~/.agenthub/global unless
+the "global_dir" config key names another, and the entrypoint of each tool package is
+exposed as a command in its bin directory, which should be on PATH.
+
+Metadata and archives read from HTTP registries are cached, so --offline can install
+from the cache and agenthub.lock alone, and --prefer-offline only goes to the network
+for what is not cached.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		frozen, _ := cmd.Flags().GetBool("frozen-lockfile")
+		version, _ := cmd.Flags().GetString("version")
+		dev, _ := cmd.Flags().GetBool("dev")
+		global, _ := cmd.Flags().GetBool("global")
+		opts := commands.InstallOptions{
+			Registry:       registryLocation(cmd),
+			Token:          viper.GetString("token"),
+			FrozenLockfile: frozen,
+			Store:          viper.GetString("store"),
+			Version:        version,
+			Dev:            dev,
+			Global:         global,
+			GlobalDir:      viper.GetString("global_dir"),
+			Network:        networkMode(),
+		}
 		if len(args) == 0 {
-			fmt.Println("Installing all project dependencies...")
-			return commands.InstallAll()
+			if cmd.Flags().Changed("version") {
+				return fmt.Errorf("--version needs a package name; change the range in agentpkg.yaml to move a dependency")
+			}
+			return commands.InstallAll(opts)
 		}
 		
 		packageName := args[0]
 		fmt.Printf("Installing package: %s\n", packageName)
-		return commands.InstallPackage(packageName)
+		return commands.InstallPackage(packageName, opts)
 	},
 }
 
@@ -31,4 +63,5 @@ func init() {
 	installCmd.Flags().BoolP("dev", "d", false, "install development dependencies")
 	installCmd.Flags().String("version", "latest", "specify version to install")
 	installCmd.Flags().BoolP("global", "g", false, "install package globally")
+	installCmd.Flags().Bool("frozen-lockfile", false, "fail instead of updating agenthub.lock when it does not match the manifest")
 } 
\ No newline at end of file
@@ -37,6 +37,11 @@ func TestInstallCommandFlags(t *testing.T) {
 	assert.NotNil(t, globalFlag, "Global flag should exist")
 	assert.Equal(t, "bool", globalFlag.Value.Type())
 	assert.Equal(t, "g", globalFlag.Shorthand)
+	
+	// Test frozen lockfile flag
+	frozenFlag := cmd.Flags().Lookup("frozen-lockfile")
+	assert.NotNil(t, frozenFlag, "Frozen-lockfile flag should exist")
+	assert.Equal(t, "bool", frozenFlag.Value.Type())
 }
 
 func TestInstallCommandArgs(t *testing.T) {
@@ -44,4 +49,15 @@ func TestInstallCommandArgs(t *testing.T) {
 	
 	// Should accept 0 or 1 args (package name is optional)
 	assert.NotNil(t, cmd.Args)
-} 
\ No newline at end of file
+} 
+func TestInstallCommandVersionNeedsPackage(t *testing.T) {
+	cmd := findCommand(rootCmd, "install")
+	t.Cleanup(func() {
+		flag := cmd.Flags().Lookup("version")
+		flag.Value.Set(flag.DefValue)
+		flag.Changed = false
+	})
+	
+	_, err := executeCommand(rootCmd, "install", "--version", "1.0.0")
+	assert.ErrorContains(t, err, "--version needs a package name")
+}
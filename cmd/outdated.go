@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"agenthub/internal/commands"
+)
+
+// outdatedCmd represents the outdated command
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List dependencies that have newer versions",
+	Long: `List the dependencies of the project that have newer versions in the registry.
+
+For each one the table shows the version in agenthub.lock (Current), the newest version
+its range in the project file allows (Wanted), and the newest stable version (Latest).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return commands.ShowOutdated(commands.OutdatedOptions{
+			Registry: registryLocation(cmd),
+			Token:    viper.GetString("token"),
+			Network:  networkMode(),
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(outdatedCmd)
+}
@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutdatedCommand(t *testing.T) {
+	cmd := findCommand(rootCmd, "outdated")
+	assert.NotNil(t, cmd, "Outdated command should exist")
+	assert.Contains(t, cmd.Long, "Wanted")
+	assert.Error(t, cmd.Args(cmd, []string{"web-search"}))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"agenthub/internal/commands"
 )
 
@@ -19,7 +20,13 @@ This will make your package available for others to install and use.`,
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
 		private, _ := cmd.Flags().GetBool("private")
 		
-		return commands.PublishPackage(dryRun, private)
+		return commands.PublishPackage(commands.PublishOptions{
+			DryRun:   dryRun,
+			Private:  private,
+			Registry: registryLocation(cmd),
+			Token:    viper.GetString("token"),
+			Network:  networkMode(),
+		})
 	},
 }
 
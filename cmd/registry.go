@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"agenthub/internal/commands"
+)
+
+// registryCmd groups the registry management commands
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage AgentHub registries",
+	Long: `Manage AgentHub registries.
+Use "agenthub registry serve" to host a private registry for your team.`,
+}
+
+// registryServeCmd represents the registry serve command
+var registryServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a registry over HTTP",
+	Long: `Serve the AgentHub registry HTTP protocol from a local storage directory.
+Publishing requires one of the configured tokens. Packages published with
+--private are only visible to clients that present a token.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		storage, _ := cmd.Flags().GetString("storage")
+		tokens, _ := cmd.Flags().GetStringArray("token")
+		if len(tokens) == 0 {
+			tokens = viper.GetStringSlice("serve_tokens")
+		}
+		
+		return commands.ServeRegistry(commands.ServeOptions{
+			Addr:    addr,
+			Storage: storage,
+			Tokens:  tokens,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(registryCmd)
+	registryCmd.AddCommand(registryServeCmd)
+	registryServeCmd.Flags().StringP("addr", "a", ":8080", "address to listen on")
+	registryServeCmd.Flags().StringP("storage", "s", "", "directory to store packages in (default is $HOME/.agenthub/registry)")
+	registryServeCmd.Flags().StringArray("token", nil, "token accepted for publishing and private packages (repeatable)")
+}
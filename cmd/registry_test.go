@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryCommand(t *testing.T) {
+	cmd := findCommand(rootCmd, "registry")
+	assert.NotNil(t, cmd, "Registry command should exist")
+	
+	serveCmd := findCommand(cmd, "serve")
+	assert.NotNil(t, serveCmd, "Registry serve command should exist")
+}
+
+func TestRegistryServeCommandFlags(t *testing.T) {
+	cmd := findCommand(findCommand(rootCmd, "registry"), "serve")
+	
+	addrFlag := cmd.Flags().Lookup("addr")
+	assert.NotNil(t, addrFlag, "Addr flag should exist")
+	assert.Equal(t, ":8080", addrFlag.DefValue)
+	
+	storageFlag := cmd.Flags().Lookup("storage")
+	assert.NotNil(t, storageFlag, "Storage flag should exist")
+	assert.Equal(t, "s", storageFlag.Shorthand)
+	
+	tokenFlag := cmd.Flags().Lookup("token")
+	assert.NotNil(t, tokenFlag, "Token flag should exist")
+	assert.Equal(t, "stringArray", tokenFlag.Value.Type())
+}
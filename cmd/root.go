@@ -6,6 +6,7 @@ import (
     
     "github.com/spf13/cobra"
     "github.com/spf13/viper"
+    "agenthub/internal/registry"
 )
 
 var cfgFile string
@@ -30,8 +31,35 @@ func init() {
     
     rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.agenthub.yaml)")
     rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+    rootCmd.PersistentFlags().Bool("offline", false, "only use cached registry data and archives, never the network")
+    rootCmd.PersistentFlags().Bool("prefer-offline", false, "use cached registry data when present and the network only for the rest")
     
     viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+    viper.BindPFlag("offline", rootCmd.PersistentFlags().Lookup("offline"))
+    viper.BindPFlag("prefer_offline", rootCmd.PersistentFlags().Lookup("prefer-offline"))
+}
+
+// registryLocation returns the registry selected by the command's
+// --registry flag, falling back to the "registry" config key. An empty
+// result selects the default registry.
+func registryLocation(cmd *cobra.Command) string {
+    if flag := cmd.Flags().Lookup("registry"); flag != nil && flag.Changed {
+        return flag.Value.String()
+    }
+    return viper.GetString("registry")
+}
+
+// networkMode returns how HTTP registries are used, from the --offline
+// and --prefer-offline flags or the "offline" and "prefer_offline" config
+// keys. Offline wins when both are set.
+func networkMode() registry.NetworkMode {
+    switch {
+    case viper.GetBool("offline"):
+        return registry.Offline
+    case viper.GetBool("prefer_offline"):
+        return registry.PreferOffline
+    }
+    return registry.Online
 }
 
 // initConfig reads in config file and ENV variables if set.
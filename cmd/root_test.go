@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"testing"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"agenthub/internal/registry"
 )
 
 func TestRootCommand(t *testing.T) {
@@ -29,4 +31,19 @@ func TestRootCommandFlags(t *testing.T) {
 	// Test persistent flags
 	assert.NotNil(t, rootCmd.PersistentFlags().Lookup("config"))
 	assert.NotNil(t, rootCmd.PersistentFlags().Lookup("verbose"))
+	assert.NotNil(t, rootCmd.PersistentFlags().Lookup("offline"))
+	assert.NotNil(t, rootCmd.PersistentFlags().Lookup("prefer-offline"))
+}
+
+func TestNetworkMode(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Set("offline", false)
+		viper.Set("prefer_offline", false)
+	})
+
+	assert.Equal(t, registry.Online, networkMode())
+	viper.Set("prefer_offline", true)
+	assert.Equal(t, registry.PreferOffline, networkMode())
+	viper.Set("offline", true)
+	assert.Equal(t, registry.Offline, networkMode())
 } 
\ No newline at end of file
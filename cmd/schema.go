@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"agenthub/internal/commands"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of agentpkg.yaml",
+	Long: `Print the JSON Schema of the agentpkg.yaml manifest format, generated
+from the same definitions agenthub validates manifests with.
+
+Editors using the YAML language server give completion and inline errors
+once the schema is referenced from the top of the manifest:
+
+  agenthub schema -o agentpkg.schema.json
+
+  # yaml-language-server: $schema=./agentpkg.schema.json
+  name: my-agent`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		
+		return commands.WriteSchema(commands.SchemaOptions{
+			Output: output,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	schemaCmd.Flags().StringP("output", "o", "", "write the schema to a file instead of printing it")
+}
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaCommand(t *testing.T) {
+	cmd := findCommand(rootCmd, "schema")
+	assert.NotNil(t, cmd, "Schema command should exist")
+	assert.Contains(t, cmd.Long, "yaml-language-server")
+
+	outputFlag := cmd.Flags().Lookup("output")
+	assert.NotNil(t, outputFlag, "Output flag should exist")
+	assert.Equal(t, "o", outputFlag.Shorthand)
+	assert.Equal(t, "", outputFlag.DefValue)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"agenthub/internal/commands"
+)
+
+// uninstallCmd represents the uninstall command
+var uninstallCmd = &cobra.Command{
+	Use:     "uninstall <package-name>...",
+	Aliases: []string{"remove", "rm"},
+	Short:   "Remove packages from the project",
+	Long: `Remove packages from the dependencies or devDependencies of the project file.
+
+The remaining dependencies are resolved again, agenthub.lock is updated, and packages
+no longer needed, including those only the removed packages depended on, are deleted
+from agent_modules.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		global, _ := cmd.Flags().GetBool("global")
+		
+		return commands.UninstallPackages(args, commands.UninstallOptions{
+			Registry:  registryLocation(cmd),
+			Token:     viper.GetString("token"),
+			Store:     viper.GetString("store"),
+			Global:    global,
+			GlobalDir: viper.GetString("global_dir"),
+			Network:   networkMode(),
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uninstallCmd)
+	uninstallCmd.Flags().BoolP("global", "g", false, "remove globally installed packages")
+}
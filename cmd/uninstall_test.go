@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUninstallCommand(t *testing.T) {
+	cmd := findCommand(rootCmd, "uninstall")
+	assert.NotNil(t, cmd, "Uninstall command should exist")
+	assert.Equal(t, []string{"remove", "rm"}, cmd.Aliases)
+	assert.Error(t, cmd.Args(cmd, nil), "at least one package is required")
+	assert.NoError(t, cmd.Args(cmd, []string{"a", "b"}))
+
+	globalFlag := cmd.Flags().Lookup("global")
+	assert.NotNil(t, globalFlag, "Global flag should exist")
+	assert.Equal(t, "g", globalFlag.Shorthand)
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"agenthub/internal/commands"
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update [package-name]...",
+	Short: "Update packages to newer versions",
+	Long: `Update the named dependencies, or all of them, to the newest versions their ranges
+in the project file allow, and update agenthub.lock and agent_modules to match.
+
+With --latest, ranges that do not allow the latest version are changed in the project
+file so they do: a tilde range stays a tilde range, an exact version stays exact, and
+any other range becomes a caret range.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		latest, _ := cmd.Flags().GetBool("latest")
+		
+		return commands.UpdatePackages(args, commands.UpdateOptions{
+			Registry: registryLocation(cmd),
+			Token:    viper.GetString("token"),
+			Store:    viper.GetString("store"),
+			Latest:   latest,
+			Network:  networkMode(),
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().Bool("latest", false, "update to the latest versions, changing ranges in the project file")
+}
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateCommand(t *testing.T) {
+	cmd := findCommand(rootCmd, "update")
+	assert.NotNil(t, cmd, "Update command should exist")
+	assert.Equal(t, "update [package-name]...", cmd.Use)
+
+	latestFlag := cmd.Flags().Lookup("latest")
+	assert.NotNil(t, latestFlag, "Latest flag should exist")
+	assert.Equal(t, "bool", latestFlag.Value.Type())
+	assert.Equal(t, "false", latestFlag.DefValue)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"agenthub/internal/commands"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [path...]",
+	Short: "Validate packages without building them",
+	Long: `Validate the manifest of each package and the files it refers to,
+without building. Without a path the package in the current directory is
+validated.
+
+Every problem is reported with its file, line and field. Use --format json
+or --format sarif for machine-readable output, such as SARIF for code
+scanning annotations in CI.
+
+Exit codes: 0 when every package is valid, 1 when any package has errors,
+and 2 when there are only warnings.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		
+		return commands.ValidatePackages(args, commands.ValidateOptions{
+			Format: format,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringP("format", "f", "text", fmt.Sprintf("output format (%s)", strings.Join(commands.ValidationFormats, ", ")))
+}
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCommand(t *testing.T) {
+	cmd := findCommand(rootCmd, "validate")
+	assert.NotNil(t, cmd, "Validate command should exist")
+	assert.Equal(t, "validate [path...]", cmd.Use)
+
+	formatFlag := cmd.Flags().Lookup("format")
+	assert.NotNil(t, formatFlag, "Format flag should exist")
+	assert.Equal(t, "text", formatFlag.DefValue)
+	assert.Equal(t, "f", formatFlag.Shorthand)
+}
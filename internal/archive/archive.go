@@ -0,0 +1,300 @@
+// Package archive reads and writes package archives, which are
+// gzip-compressed tarballs of a package's manifest and content.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"agenthub/pkg"
+)
+
+// Files lists the files of the package in dir that belong in its archive:
+// every regular file not ignored by DefaultIgnore, the package's ignore
+// file or the extra patterns in exclude. The manifest is always included.
+// Paths are slash-separated, relative to dir and sorted.
+func Files(dir string, exclude ...string) ([]string, error) {
+	manifest, err := pkg.FindAgentPkg(dir)
+	if err != nil {
+		return nil, err
+	}
+	manifest = filepath.Base(manifest)
+
+	ig, err := LoadIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range exclude {
+		ig.Add(p)
+	}
+
+	var files []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == manifest {
+			files = append(files, rel)
+			return nil
+		}
+		if ig.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list package files: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// FileEntry describes one file in an archive.
+type FileEntry struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	// Digest is the SHA-256 of the file, formatted as "sha256:<hex>".
+	Digest string `json:"digest"`
+}
+
+// modTime is the modification time recorded for every archive entry.
+// Together with sorted entries, normalised ownership and permissions, and a
+// fixed compression level it makes archives reproducible: packing the same
+// files yields the same bytes on every machine.
+var modTime = time.Unix(0, 0).UTC()
+
+// Pack writes a gzip-compressed tarball of files, given relative to dir,
+// to w and describes each file it wrote, in archive order. Entries are
+// sorted by path and their metadata normalised, so the output depends only
+// on the paths, contents and executable bits of the files.
+func Pack(w io.Writer, dir string, files []string) ([]FileEntry, error) {
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	gz.ModTime = modTime
+	tw := tar.NewWriter(gz)
+
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+
+	entries := make([]FileEntry, 0, len(sorted))
+	for _, name := range sorted {
+		entry, err := addFile(tw, dir, name)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return entries, nil
+}
+
+func addFile(tw *tar.Writer, dir, name string) (FileEntry, error) {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return FileEntry{}, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return FileEntry{}, fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+	if !info.Mode().IsRegular() {
+		return FileEntry{}, fmt.Errorf("failed to archive %s: not a regular file", name)
+	}
+	mode := int64(0644)
+	if info.Mode()&0111 != 0 {
+		mode = 0755
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     mode,
+		Size:     info.Size(),
+		ModTime:  modTime,
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return FileEntry{}, fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tw, hash), f)
+	if err != nil {
+		return FileEntry{}, fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return FileEntry{Path: name, Size: size, Digest: digest(hash)}, nil
+}
+
+func digest(h hash.Hash) string {
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Limits bound what reading or extracting an archive may produce, so a
+// malicious archive cannot exhaust memory or disk.
+type Limits struct {
+	// MaxFiles is the largest number of files
+	MaxFiles int
+	// MaxFileSize is the largest size of one file, in bytes
+	MaxFileSize int64
+	// MaxTotalSize is the largest total size of all files, in bytes
+	MaxTotalSize int64
+}
+
+// DefaultLimits are the limits used for package archives.
+var DefaultLimits = Limits{
+	MaxFiles:     10000,
+	MaxFileSize:  512 << 20,
+	MaxTotalSize: 1 << 30,
+}
+
+// walk calls fn for each regular file of a gzip-compressed tarball, with
+// its cleaned slash-separated path. Archives that could write outside the
+// extraction directory, through an absolute or parent path or a link, or
+// that exceed limits are rejected before fn sees the offending entry.
+func walk(r io.Reader, limits Limits, fn func(name string, hdr *tar.Header, r io.Reader) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("invalid archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	files, total := 0, int64(0)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid archive: %w", err)
+		}
+
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || strings.Contains(hdr.Name, "\\") {
+			return fmt.Errorf("invalid archive: unsafe path %q", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeSymlink, tar.TypeLink:
+			target := hdr.Linkname
+			if hdr.Typeflag == tar.TypeSymlink {
+				target = path.Join(path.Dir(name), target)
+			}
+			target = path.Clean(target)
+			if path.IsAbs(hdr.Linkname) || target == ".." || strings.HasPrefix(target, "../") {
+				return fmt.Errorf("invalid archive: link %q points outside the package", hdr.Name)
+			}
+			return fmt.Errorf("invalid archive: link %q is not allowed", hdr.Name)
+		default:
+			return fmt.Errorf("invalid archive: %q has unsupported type %q", hdr.Name, hdr.Typeflag)
+		}
+		if name == "." {
+			return fmt.Errorf("invalid archive: unsafe path %q", hdr.Name)
+		}
+
+		files++
+		total += hdr.Size
+		switch {
+		case files > limits.MaxFiles:
+			return fmt.Errorf("invalid archive: more than %d files", limits.MaxFiles)
+		case hdr.Size < 0 || hdr.Size > limits.MaxFileSize:
+			return fmt.Errorf("invalid archive: %s is larger than %d bytes", name, limits.MaxFileSize)
+		case total > limits.MaxTotalSize:
+			return fmt.Errorf("invalid archive: contents are larger than %d bytes", limits.MaxTotalSize)
+		}
+		if err := fn(name, hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// Read returns the regular files of a gzip-compressed tarball, keyed by
+// their slash-separated paths. Archives that are unsafe to extract or
+// exceed DefaultLimits are rejected; directory entries are skipped.
+func Read(r io.Reader) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := walk(r, DefaultLimits, func(name string, hdr *tar.Header, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("invalid archive: %w", err)
+		}
+		files[name] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Extract unpacks the regular files of a gzip-compressed tarball into dir,
+// which must not exist yet, keeping their executable bit. Archives are
+// checked as by Read, against limits.
+func Extract(r io.Reader, dir string, limits Limits) error {
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+	return walk(r, limits, func(name string, hdr *tar.Header, r io.Reader) error {
+		mode := os.FileMode(0644)
+		if hdr.Mode&0111 != 0 {
+			mode = 0755
+		}
+		if err := extractFile(r, filepath.Join(dir, filepath.FromSlash(name)), mode); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	// O_EXCL refuses to write through anything already at target,
+	// including a second entry with the same path.
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
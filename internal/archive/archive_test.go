@@ -0,0 +1,279 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+// readArchive returns the contents of every file in a packed archive.
+func readArchive(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	files := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(content)
+	}
+	return files
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "agentpkg.yaml", "name: a\n")
+	writeFile(t, dir, "tools/search/tool.py", "print()\n")
+	writeFile(t, dir, "prompts/summary.md", "Summarize\n")
+	writeFile(t, dir, "README.md", "# a\n")
+	writeFile(t, dir, "notes/todo.txt", "not packed\n")
+	writeFile(t, dir, "scratch.log", "not packed\n")
+	writeFile(t, dir, "keep.log", "packed\n")
+	writeFile(t, dir, ".env", "SECRET=1\n")
+	writeFile(t, dir, ".git/HEAD", "ref: refs/heads/main\n")
+	writeFile(t, dir, "dist/a-1.0.0.tgz", "old build\n")
+	writeFile(t, dir, IgnoreFileName, "notes/\n*.log\n!keep.log\n")
+
+	files, err := Files(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		IgnoreFileName,
+		"README.md",
+		"agentpkg.yaml",
+		"keep.log",
+		"prompts/summary.md",
+		"tools/search/tool.py",
+	}, files)
+
+	files, err = Files(dir, "/tools/")
+	require.NoError(t, err)
+	assert.NotContains(t, files, "tools/search/tool.py")
+
+	_, err = Files(t.TempDir())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFilesAlwaysIncludesManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "agentpkg.yaml", "name: a\n")
+	writeFile(t, dir, IgnoreFileName, "*.yaml\n")
+
+	files, err := Files(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{IgnoreFileName, "agentpkg.yaml"}, files)
+}
+
+func TestPack(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "agentpkg.yaml", "name: a\n")
+	writeFile(t, dir, "agents/agent.yaml", "model: x\n")
+
+	var buf bytes.Buffer
+	entries, err := Pack(&buf, dir, []string{"agentpkg.yaml", "agents/agent.yaml"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, FileEntry{
+		Path:   "agentpkg.yaml",
+		Size:   8,
+		Digest: "sha256:" + sha256Hex("name: a\n"),
+	}, entries[0])
+	assert.Equal(t, map[string]string{
+		"agentpkg.yaml":     "name: a\n",
+		"agents/agent.yaml": "model: x\n",
+	}, readArchive(t, buf.Bytes()))
+
+	_, err = Pack(io.Discard, dir, []string{"missing.txt"})
+	assert.ErrorContains(t, err, "failed to open missing.txt")
+}
+
+func TestPackIsReproducible(t *testing.T) {
+	pack := func(dir string, files []string) []byte {
+		var buf bytes.Buffer
+		_, err := Pack(&buf, dir, files)
+		require.NoError(t, err)
+		return buf.Bytes()
+	}
+
+	first := t.TempDir()
+	writeFile(t, first, "agentpkg.yaml", "name: a\n")
+	writeFile(t, first, "tools/run.sh", "#!/bin/sh\n")
+	require.NoError(t, os.Chmod(filepath.Join(first, "tools/run.sh"), 0700))
+
+	second := t.TempDir()
+	writeFile(t, second, "agentpkg.yaml", "name: a\n")
+	writeFile(t, second, "tools/run.sh", "#!/bin/sh\n")
+	require.NoError(t, os.Chmod(filepath.Join(second, "tools/run.sh"), 0775))
+	old := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(second, "agentpkg.yaml"), old, old))
+
+	a := pack(first, []string{"agentpkg.yaml", "tools/run.sh"})
+	b := pack(second, []string{"tools/run.sh", "agentpkg.yaml"})
+	assert.Equal(t, a, b)
+
+	gz, err := gzip.NewReader(bytes.NewReader(a))
+	require.NoError(t, err)
+	assert.Empty(t, gz.Name)
+	tr := tar.NewReader(gz)
+	for _, want := range []struct {
+		name string
+		mode int64
+	}{{"agentpkg.yaml", 0644}, {"tools/run.sh", 0755}} {
+		hdr, err := tr.Next()
+		require.NoError(t, err)
+		assert.Equal(t, want.name, hdr.Name)
+		assert.Equal(t, want.mode, hdr.Mode)
+		assert.Equal(t, 0, hdr.Uid)
+		assert.Empty(t, hdr.Uname)
+		assert.Equal(t, int64(0), hdr.ModTime.Unix())
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "agentpkg.yaml", "name: a\n")
+	writeFile(t, dir, "prompts/system.md", "Hello\n")
+	var buf bytes.Buffer
+	_, err := Pack(&buf, dir, []string{"agentpkg.yaml", "prompts/system.md"})
+	require.NoError(t, err)
+
+	files, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"agentpkg.yaml":     []byte("name: a\n"),
+		"prompts/system.md": []byte("Hello\n"),
+	}, files)
+
+	buf.Reset()
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "../escape", Size: 1, Mode: 0644}))
+	_, err = tw.Write([]byte("x"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	_, err = Read(&buf)
+	assert.ErrorContains(t, err, "unsafe path")
+
+	_, err = Read(bytes.NewReader([]byte("not gzip")))
+	assert.ErrorContains(t, err, "invalid archive")
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "agentpkg.yaml", "name: a\n")
+	writeFile(t, dir, "tools/run.sh", "#!/bin/sh\n")
+	require.NoError(t, os.Chmod(filepath.Join(dir, "tools", "run.sh"), 0755))
+	var buf bytes.Buffer
+	_, err := Pack(&buf, dir, []string{"agentpkg.yaml", "tools/run.sh"})
+	require.NoError(t, err)
+
+	target := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, Extract(&buf, target, DefaultLimits))
+	data, err := os.ReadFile(filepath.Join(target, "agentpkg.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: a\n", string(data))
+	info, err := os.Stat(filepath.Join(target, "tools", "run.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	// The target directory must be new.
+	buf.Reset()
+	_, err = Pack(&buf, dir, []string{"agentpkg.yaml"})
+	require.NoError(t, err)
+	assert.Error(t, Extract(&buf, target, DefaultLimits))
+}
+
+// rawArchive returns a gzip-compressed tarball of the given headers, each
+// followed by Size bytes of content.
+func rawArchive(t *testing.T, headers ...*tar.Header) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, hdr := range headers {
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write(bytes.Repeat([]byte("x"), int(hdr.Size)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestExtractRejectsUnsafeArchives(t *testing.T) {
+	file := func(name string, size int64) *tar.Header {
+		return &tar.Header{Typeflag: tar.TypeReg, Name: name, Size: size, Mode: 0644}
+	}
+	limits := Limits{MaxFiles: 3, MaxFileSize: 100, MaxTotalSize: 150}
+
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+		err     string
+	}{
+		{"parent path", []*tar.Header{file("../evil", 1)}, `unsafe path "../evil"`},
+		{"nested parent path", []*tar.Header{file("a/../../evil", 1)}, "unsafe path"},
+		{"absolute path", []*tar.Header{file("/etc/passwd", 1)}, "unsafe path"},
+		{"backslash path", []*tar.Header{file(`..\evil`, 1)}, "unsafe path"},
+		{"symlink escape", []*tar.Header{{Typeflag: tar.TypeSymlink, Name: "a/link", Linkname: "../../etc"}}, `link "a/link" points outside the package`},
+		{"absolute symlink", []*tar.Header{{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "/etc/passwd"}}, "points outside the package"},
+		{"symlink inside", []*tar.Header{{Typeflag: tar.TypeSymlink, Name: "a/link", Linkname: "b"}}, `link "a/link" is not allowed`},
+		{"hard link escape", []*tar.Header{{Typeflag: tar.TypeLink, Name: "link", Linkname: "../x"}}, "points outside the package"},
+		{"device", []*tar.Header{{Typeflag: tar.TypeChar, Name: "tty"}}, "unsupported type"},
+		{"too many files", []*tar.Header{file("a", 1), file("b", 1), file("c", 1), file("d", 1)}, "more than 3 files"},
+		{"file too large", []*tar.Header{file("big", 101)}, "big is larger than 100 bytes"},
+		{"total too large", []*tar.Header{file("a", 100), file("b", 60)}, "contents are larger than 150 bytes"},
+		{"duplicate file", []*tar.Header{file("a", 1), file("a", 1)}, "failed to extract a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			target := filepath.Join(parent, "out")
+			err := Extract(bytes.NewReader(rawArchive(t, tt.headers...)), target, limits)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+
+			// Nothing was written outside the target directory.
+			entries, err := os.ReadDir(parent)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, "out", entries[0].Name())
+		})
+	}
+}
+
+func TestReadRejectsUnsafeArchives(t *testing.T) {
+	data := rawArchive(t, &tar.Header{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "/etc/passwd"})
+	_, err := Read(bytes.NewReader(data))
+	assert.ErrorContains(t, err, "points outside the package")
+}
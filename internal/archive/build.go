@@ -0,0 +1,115 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"agenthub/pkg"
+)
+
+// Metadata describes a built archive. It is written next to the archive
+// with the same base name and a .json extension.
+type Metadata struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Archive is the file name of the archive in the output directory.
+	Archive string `json:"archive"`
+	// Digest is the SHA-256 of the archive, formatted as "sha256:<hex>".
+	Digest string      `json:"digest"`
+	Size   int64       `json:"size"`
+	Files  []FileEntry `json:"files"`
+}
+
+// BaseName returns the file name, without extension, used for the build
+// output of a package: <name>-<version>, with a scoped name such as
+// @team/tool flattened to team-tool.
+func BaseName(name, version string) string {
+	name = strings.TrimPrefix(name, "@")
+	name = strings.ReplaceAll(name, "/", "-")
+	return name + "-" + version
+}
+
+// BuildFiles lists the files Build packs for the package in dir: those of
+// Files, leaving out outputDir when it is inside the package.
+func BuildFiles(dir, outputDir string) ([]string, error) {
+	var exclude []string
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	absOutput, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	if rel, err := filepath.Rel(absDir, absOutput); err == nil && rel != "." && rel != ".." &&
+		!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		exclude = append(exclude, "/"+filepath.ToSlash(rel)+"/")
+	}
+	return Files(dir, exclude...)
+}
+
+// Build packs the package in dir into outputDir as <base>.tgz and writes
+// its metadata to <base>.json, returning the metadata.
+func Build(dir, outputDir string, agentPkg *pkg.AgentPkg) (*Metadata, error) {
+	files, err := BuildFiles(dir, outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	base := BaseName(agentPkg.Name, agentPkg.Version)
+	archivePath := filepath.Join(outputDir, base+".tgz")
+	tmp, err := os.CreateTemp(outputDir, "."+base+".tgz.tmp*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(tmp, hash)}
+	entries, err := Pack(counter, dir, files)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write archive: %w", closeErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), archivePath); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	meta := &Metadata{
+		Name:    agentPkg.Name,
+		Version: agentPkg.Version,
+		Archive: base + ".tgz",
+		Digest:  digest(hash),
+		Size:    counter.n,
+		Files:   entries,
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, base+".json"), append(data, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write metadata: %w", err)
+	}
+	return meta, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
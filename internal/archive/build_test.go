@@ -0,0 +1,48 @@
+package archive
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"agenthub/pkg"
+)
+
+func TestBaseName(t *testing.T) {
+	assert.Equal(t, "web-search-1.0.0", BaseName("web-search", "1.0.0"))
+	assert.Equal(t, "team-tool-2.0.0-beta.1", BaseName("@team/tool", "2.0.0-beta.1"))
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "agentpkg.yaml", "name: web-search\nversion: 1.0.0\n")
+	writeFile(t, dir, "tools/search.py", "print('search')\n")
+	out := t.TempDir()
+
+	meta, err := Build(dir, out, &pkg.AgentPkg{Name: "web-search", Version: "1.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "web-search-1.0.0.tgz", meta.Archive)
+	assert.Len(t, meta.Files, 2)
+
+	data, err := os.ReadFile(filepath.Join(out, meta.Archive))
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:"+sha256Hex(string(data)), meta.Digest)
+	assert.Equal(t, map[string]string{
+		"agentpkg.yaml":   "name: web-search\nversion: 1.0.0\n",
+		"tools/search.py": "print('search')\n",
+	}, readArchive(t, data))
+
+	var sidecar Metadata
+	sidecarData, err := os.ReadFile(filepath.Join(out, "web-search-1.0.0.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(sidecarData, &sidecar))
+	assert.Equal(t, *meta, sidecar)
+
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary files are left behind")
+}
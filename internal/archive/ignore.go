@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the file listing, in .gitignore syntax, the paths that
+// are left out of a package's archive.
+const IgnoreFileName = ".agenthubignore"
+
+// DefaultIgnore are the patterns ignored in every package, before the rules
+// of its ignore file. An ignore file can re-include them with a negation.
+var DefaultIgnore = []string{
+	".git/",
+	".hg/",
+	".svn/",
+	".DS_Store",
+	"dist/",
+	"agent_modules/",
+	".env",
+	".env.*",
+	"*.pem",
+}
+
+// Ignore is a list of gitignore-style rules. As in .gitignore, the last
+// matching rule decides whether a path is ignored, a leading "!" negates a
+// rule, a trailing "/" matches only directories, a pattern containing a
+// "/" other than a trailing one is anchored to the package root, and "**"
+// matches any number of directories.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// NewIgnore returns rules made of DefaultIgnore followed by patterns.
+func NewIgnore(patterns ...string) *Ignore {
+	ig := &Ignore{}
+	for _, p := range DefaultIgnore {
+		ig.Add(p)
+	}
+	for _, p := range patterns {
+		ig.Add(p)
+	}
+	return ig
+}
+
+// LoadIgnore returns the default rules followed by those of the ignore file
+// in dir, if there is one.
+func LoadIgnore(dir string) (*Ignore, error) {
+	ig := NewIgnore()
+	f, err := os.Open(filepath.Join(dir, IgnoreFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return ig, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+	defer f.Close()
+	if err := ig.Parse(f); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+	return ig, nil
+}
+
+// Parse adds a rule for every pattern line read from r. Blank lines and
+// lines starting with "#" are skipped; a leading backslash escapes a "#"
+// or "!".
+func (ig *Ignore) Parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		ig.Add(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// Add adds the rule for one pattern line.
+func (ig *Ignore) Add(line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	rule.segments = strings.Split(line, "/")
+	if !anchored {
+		rule.segments = append([]string{"**"}, rule.segments...)
+	}
+	ig.rules = append(ig.rules, rule)
+}
+
+// Match reports whether the slash-separated path, relative to the package
+// root, is ignored. isDir says whether the path is a directory.
+func (ig *Ignore) Match(name string, isDir bool) bool {
+	segments := strings.Split(name, "/")
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(rule.segments, segments) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreMatch(t *testing.T) {
+	ig := &Ignore{}
+	require.NoError(t, ig.Parse(strings.NewReader(`
+# comments and blank lines are skipped
+
+*.log
+!important.log
+build/
+/secrets.txt
+docs/*.draft.md
+data/**/raw
+**/cache
+\#literal
+`)))
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"debug.log", false, true},
+		{"tools/debug.log", false, true},
+		{"important.log", false, false},
+		{"tools/important.log", false, false},
+		{"build", true, true},
+		{"tools/build", true, true},
+		{"build", false, false},
+		{"secrets.txt", false, true},
+		{"tools/secrets.txt", false, false},
+		{"docs/intro.draft.md", false, true},
+		{"docs/nested/intro.draft.md", false, false},
+		{"docs/intro.md", false, false},
+		{"data/raw", true, true},
+		{"data/a/b/raw", true, true},
+		{"cache", true, true},
+		{"prompts/cache", true, true},
+		{"#literal", false, true},
+		{"prompts/system.md", false, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.ignored, ig.Match(tt.path, tt.isDir), tt.path)
+	}
+}
+
+func TestNewIgnoreDefaults(t *testing.T) {
+	ig := NewIgnore()
+	assert.True(t, ig.Match(".git", true))
+	assert.True(t, ig.Match("dist", true))
+	assert.True(t, ig.Match(".env", false))
+	assert.True(t, ig.Match("tools/.env.local", false))
+	assert.True(t, ig.Match("agent_modules", true))
+
+	// Negations re-include defaults.
+	ig = NewIgnore("!dist/")
+	assert.False(t, ig.Match("dist", true))
+}
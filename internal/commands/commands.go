@@ -1,88 +1,926 @@
 package commands
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"agenthub/internal/archive"
+	"agenthub/internal/registry"
+	"agenthub/internal/store"
+	"agenthub/internal/templates"
+	"agenthub/pkg"
 )
 
+// InitOptions controls how a new project is initialized
+type InitOptions struct {
+	// Name is the package name written to the manifest; empty uses the
+	// name of the project directory
+	Name string
+	// Version is the initial package version; empty uses 0.1.0, or the
+	// template's version
+	Version     string
+	Description string
+	Author      string
+	License     string
+	// Dependencies are written to the manifest as the project's initial
+	// dependencies
+	Dependencies map[string]string
+	// Registry is the location of the registry the project uses; it is
+	// recorded in the manifest unless empty or "default"
+	Registry string
+	// Template is the built-in template, or registry package
+	// (name[@version]), the project is scaffolded from; empty creates the
+	// bare directory structure
+	Template string
+	// Force allows initializing a directory that is not empty, replacing
+	// any files the project would create
+	Force bool
+	// Interactive asks for the package details on the terminal, using the
+	// other options as defaults
+	Interactive bool
+	// Network selects whether a registry template is read from the
+	// registry or the offline cache of HTTP registries
+	Network registry.NetworkMode
+}
+
+// defaultIgnoreFile is the .agenthubignore written into new projects
+const defaultIgnoreFile = `# Files left out of the package archive, in .gitignore syntax.
+# .git, dist, agent_modules and .env files are always left out.
+*.log
+tmp/
+`
+
 // InitProject initializes a new AgentHub project
-func InitProject(projectName string) error {
+func InitProject(projectName string, opts InitOptions) error {
+	if opts.Interactive {
+		if isTerminal(os.Stdin) {
+			var err error
+			if opts, err = runInitWizard(os.Stdin, os.Stdout, projectName, opts); err != nil {
+				return err
+			}
+		} else {
+			fmt.Println("stdin is not a terminal; skipping the interactive wizard and using flags and defaults")
+		}
+	}
 	fmt.Printf("Creating new AgentHub project: %s\n", projectName)
 	
+	absProject, err := filepath.Abs(projectName)
+	if err != nil {
+		return fmt.Errorf("invalid project directory %q: %w", projectName, err)
+	}
+	starter := &pkg.AgentPkg{
+		Name:         opts.Name,
+		Version:      opts.Version,
+		Description:  opts.Description,
+		Author:       opts.Author,
+		License:      opts.License,
+		Dependencies: opts.Dependencies,
+	}
+	if starter.Name == "" {
+		starter.Name = filepath.Base(absProject)
+	}
+	if starter.Version == "" {
+		starter.Version = "0.1.0"
+	}
+	if err := pkg.ValidateAgentPkg(starter); err != nil {
+		return err
+	}
+	if err := pkg.ValidateName(starter.Name); err != nil {
+		return fmt.Errorf("%w; choose another with --name", err)
+	}
+	if _, err := pkg.ParseVersion(starter.Version); err != nil {
+		return err
+	}
+	
+	var reg registry.Registry
+	if opts.Registry != "" {
+		reg, err = registry.Open(opts.Registry, registry.Options{Network: opts.Network})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Using registry: %s\n", reg.Location())
+		if opts.Registry != "default" {
+			starter.Registry = opts.Registry
+		}
+	}
+	
+	var tmpl *templates.Template
+	if opts.Template != "" {
+		if tmpl, err = findTemplate(opts.Template, reg, opts.Network); err != nil {
+			return err
+		}
+		fmt.Printf("Using template: %s\n", tmpl.Name)
+	}
+	
+	if entries, err := os.ReadDir(projectName); err == nil && len(entries) > 0 && !opts.Force {
+		return fmt.Errorf("directory %s is not empty; use --force to initialize it anyway", projectName)
+	}
+	
 	// Create project directory
 	if err := os.MkdirAll(projectName, 0755); err != nil {
 		return fmt.Errorf("failed to create project directory: %w", err)
 	}
 	
-	// Create basic project structure
-	dirs := []string{
-		filepath.Join(projectName, "agents"),
-		filepath.Join(projectName, "tools"),
-		filepath.Join(projectName, "chains"),
-		filepath.Join(projectName, "prompts"),
-		filepath.Join(projectName, "datasets"),
+	var created []string
+	if tmpl != nil {
+		files, err := tmpl.Render(projectName, templates.Vars{Name: starter.Name, Author: starter.Author}, opts.Force)
+		if err != nil {
+			return err
+		}
+		created = files
+	} else {
+		// Create basic project structure
+		dirs := []string{
+			filepath.Join(projectName, "agents"),
+			filepath.Join(projectName, "tools"),
+			filepath.Join(projectName, "chains"),
+			filepath.Join(projectName, "prompts"),
+			filepath.Join(projectName, "datasets"),
+		}
+		
+		for _, dir := range dirs {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", dir, err)
+			}
+		}
 	}
 	
-	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	manifestPath, err := writeStarterManifest(projectName, starter, opts)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(created, filepath.Base(manifestPath)) {
+		created = append(created, filepath.Base(manifestPath))
+	}
+	
+	if !slices.Contains(created, archive.IgnoreFileName) {
+		ignorePath := filepath.Join(projectName, archive.IgnoreFileName)
+		if _, err := os.Stat(ignorePath); opts.Force || errors.Is(err, os.ErrNotExist) {
+			if err := os.WriteFile(ignorePath, []byte(defaultIgnoreFile), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", archive.IgnoreFileName, err)
+			}
+			created = append(created, archive.IgnoreFileName)
 		}
 	}
 	
+	sort.Strings(created)
+	for _, f := range created {
+		fmt.Printf("  + %s\n", f)
+	}
 	fmt.Printf("✅ Successfully initialized project: %s\n", projectName)
 	return nil
 }
 
-// InstallAll installs all project dependencies
-func InstallAll() error {
+// writeStarterManifest writes the manifest of a new project. A manifest
+// written by a template is kept, with the fields given in opts applied on
+// top of it; otherwise starter is written to agentpkg.yaml.
+func writeStarterManifest(projectName string, starter *pkg.AgentPkg, opts InitOptions) (string, error) {
+	manifestPath, err := pkg.FindAgentPkg(projectName)
+	if errors.Is(err, os.ErrNotExist) {
+		manifestPath = filepath.Join(projectName, pkg.ManifestNames[0])
+		return manifestPath, pkg.SaveAgentPkg(manifestPath, starter)
+	}
+	if err != nil {
+		return "", err
+	}
+	
+	agentPkg, err := pkg.LoadAgentPkg(manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("template manifest is invalid: %w", err)
+	}
+	// The template's manifest is only rewritten when a value differs, so
+	// its comments and layout survive a plain init
+	changed := false
+	set := func(field *string, value string) {
+		if value != "" && *field != value {
+			*field, changed = value, true
+		}
+	}
+	set(&agentPkg.Name, opts.Name)
+	set(&agentPkg.Version, opts.Version)
+	set(&agentPkg.Description, opts.Description)
+	set(&agentPkg.Author, opts.Author)
+	set(&agentPkg.License, opts.License)
+	set(&agentPkg.Registry, starter.Registry)
+	for name, constraint := range opts.Dependencies {
+		if agentPkg.Dependencies == nil {
+			agentPkg.Dependencies = make(map[string]string)
+		}
+		if agentPkg.Dependencies[name] != constraint {
+			agentPkg.Dependencies[name], changed = constraint, true
+		}
+	}
+	if err := pkg.ValidateAgentPkg(agentPkg); err != nil {
+		return "", fmt.Errorf("template manifest is invalid: %w", err)
+	}
+	if !changed {
+		return manifestPath, nil
+	}
+	return manifestPath, pkg.SaveAgentPkg(manifestPath, agentPkg)
+}
+
+// findTemplate returns the built-in template called name or, failing
+// that, the template published to reg, or the default registry opened in
+// network mode, as the package ref (name[@version])
+func findTemplate(ref string, reg registry.Registry, network registry.NetworkMode) (*templates.Template, error) {
+	if tmpl, err := templates.Lookup(ref); err == nil {
+		return tmpl, nil
+	}
+	
+	name, constraint := ref, "latest"
+	if i := strings.LastIndex(ref, "@"); i > 0 {
+		name, constraint = ref[:i], ref[i+1:]
+	}
+	if reg == nil {
+		var err error
+		if reg, err = registry.Open("", registry.Options{Network: network}); err != nil {
+			return nil, err
+		}
+	}
+	src := registry.NewSource(reg)
+	versions, err := src.Versions(name)
+	if errors.Is(err, registry.ErrNotFound) {
+		return nil, fmt.Errorf("unknown template %q: not a built-in template (%s) or a package in %s",
+			ref, strings.Join(templates.Names(), ", "), reg.Location())
+	}
+	if err != nil {
+		return nil, err
+	}
+	version, err := pkg.ResolveVersion(constraint, versions)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	
+	rc, err := reg.Fetch(name, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch template %s@%s: %w", name, version, err)
+	}
+	defer rc.Close()
+	tmpl, err := templates.FromArchive(name+"@"+version, rc)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// InstallOptions controls how project dependencies are installed
+type InstallOptions struct {
+	// Registry is the location of the registry to install from; empty
+	// selects the default registry
+	Registry string
+	// Token authenticates with HTTP registries
+	Token string
+	// FrozenLockfile makes the install fail instead of updating agenthub.lock
+	// when the lockfile does not match the manifest
+	FrozenLockfile bool
+	// Store is the directory of the package store shared by all projects;
+	// empty selects store.DefaultDir
+	Store string
+	// Version is the version range of the package to install; the
+	// version it resolves to is pinned. Empty or "latest" requires the
+	// latest version with a caret range.
+	Version string
+	// Dev records the package in devDependencies
+	Dev bool
+	// Global installs into the global prefix instead of the project in
+	// the current directory and exposes the entrypoints of tool packages
+	// in its bin directory
+	Global bool
+	// GlobalDir is the global prefix; empty selects DefaultGlobalDir
+	GlobalDir string
+	// Network selects whether the registry or the offline cache of HTTP
+	// registries is used
+	Network registry.NetworkMode
+}
+
+// packageSource is a pkg.PackageSource that can also say where a package
+// version was published and what its archive digest is, so the result can
+// be pinned in agenthub.lock, and download its archive
+type packageSource interface {
+	pkg.PackageSource
+	Location() string
+	Digest(name, version string) (string, error)
+	Fetch(name, version string) (io.ReadCloser, error)
+}
+
+// InstallAll installs all project dependencies, or all global packages
+// with opts.Global
+func InstallAll(opts InstallOptions) error {
+	dir, err := installDir(opts)
+	if err != nil {
+		return err
+	}
 	fmt.Println("Installing all project dependencies...")
-	// TODO: Implement actual dependency installation logic
+	reg, err := openRegistry(dir, opts.Registry, registry.Options{Token: opts.Token, Network: opts.Network})
+	if err != nil {
+		return err
+	}
+	if err := installAll(dir, registry.NewSource(reg), opts); err != nil {
+		return err
+	}
 	fmt.Println("✅ All dependencies installed successfully")
 	return nil
 }
 
-// InstallPackage installs a specific package
-func InstallPackage(packageName string) error {
-	fmt.Printf("Installing package: %s\n", packageName)
-	// TODO: Implement actual package installation logic
-	fmt.Printf("✅ Package %s installed successfully\n", packageName)
+// installDir returns the directory of the project opts installs into
+func installDir(opts InstallOptions) (string, error) {
+	if !opts.Global {
+		return ".", nil
+	}
+	if opts.Dev {
+		return "", fmt.Errorf("--dev cannot be used with --global")
+	}
+	return globalDir(opts)
+}
+
+// installAll resolves the dependencies of the project in dir, reusing
+// agenthub.lock when it still matches the manifest and rewriting it
+// otherwise, and installs the locked packages into agent_modules from the
+// package store
+func installAll(dir string, src packageSource, opts InstallOptions) error {
+	manifestPath, err := pkg.FindAgentPkg(dir)
+	if err != nil {
+		return err
+	}
+	agentPkg, err := pkg.LoadAgentPkg(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	lockPath := filepath.Join(dir, pkg.LockfileName)
+	lock, err := pkg.LoadLockfile(lockPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var verifyErr error
+	if lock != nil {
+		verifyErr = lock.Verify(agentPkg)
+	}
+
+	if opts.FrozenLockfile {
+		if lock == nil {
+			return fmt.Errorf("--frozen-lockfile requires an existing %s", pkg.LockfileName)
+		}
+		if verifyErr != nil {
+			return fmt.Errorf("%w\nrun `agenthub install` without --frozen-lockfile to update it", verifyErr)
+		}
+	}
+
+	if lock == nil || verifyErr != nil {
+		var preferred map[string]string
+		if lock != nil {
+			preferred = lock.Preferred()
+		}
+		if lock, err = resolveLockfile(agentPkg, src, preferred); err != nil {
+			return err
+		}
+		if err := lock.Save(lockPath); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", pkg.LockfileName)
+	} else {
+		fmt.Printf("Using %s\n", pkg.LockfileName)
+	}
+	return installLocked(dir, lock, src, opts)
+}
+
+// installLocked installs the packages in lock into agent_modules of the
+// project in dir and reports what changed
+func installLocked(dir string, lock *pkg.Lockfile, src packageSource, opts InstallOptions) error {
+	st, err := store.Open(opts.Store)
+	if err != nil {
+		return err
+	}
+	stats, err := linkModules(dir, lock, src, st)
+	if err != nil {
+		return err
+	}
+	for _, p := range lock.Resolved() {
+		fmt.Printf("  + %s@%s\n", p.Name, p.Version)
+	}
+	for _, name := range stats.Removed {
+		fmt.Printf("  - %s\n", name)
+	}
+	if stats.Linked > 0 {
+		fmt.Printf("Linked %d %s into %s (%d downloaded to %s)\n",
+			stats.Linked, plural(stats.Linked, "package", "packages"), ModulesDir, stats.Downloaded, st.Dir())
+	}
+
+	if opts.Global {
+		commands, err := linkGlobalBins(dir, lock)
+		if err != nil {
+			return err
+		}
+		bin, _ := filepath.Abs(filepath.Join(dir, GlobalBinDir))
+		for _, command := range commands {
+			fmt.Printf("  %s -> %s\n", command, bin)
+		}
+		if len(commands) > 0 && !onPath(bin) {
+			fmt.Printf("⚠️  %s is not on your PATH; add it to run the installed commands\n", bin)
+		}
+	}
 	return nil
 }
 
+// notCachedPackages returns the packages the dependencies of agentPkg may
+// need whose metadata src cannot read offline. Dependencies are followed
+// through the preferred version of each cached package, or else the newest
+// version its range allows, so one report names what a run online fetches.
+func notCachedPackages(agentPkg *pkg.AgentPkg, src packageSource, preferred map[string]string) []string {
+	ranges := make(map[string]string)
+	for name, constraint := range agentPkg.AllDependencies() {
+		ranges[name] = constraint
+	}
+	queue := sortedNames(ranges)
+	seen := make(map[string]bool)
+	var missing []string
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		versions, err := src.Versions(name)
+		var notCached *registry.NotCachedError
+		if errors.As(err, &notCached) {
+			missing = append(missing, name)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		version := preferred[name]
+		if version == "" {
+			if version, err = pkg.ResolveVersion(ranges[name], versions); err != nil {
+				continue
+			}
+		}
+		deps, err := src.Dependencies(name, version)
+		if err != nil {
+			continue
+		}
+		for _, dep := range sortedNames(deps) {
+			if _, ok := ranges[dep]; !ok {
+				ranges[dep] = deps[dep]
+			}
+			queue = append(queue, dep)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// openRegistry opens the registry at location or, when location is empty,
+// the registry recorded in the manifest of the package in dir, if any
+func openRegistry(dir, location string, opts registry.Options) (registry.Registry, error) {
+	if location == "" {
+		if manifestPath, err := pkg.FindAgentPkg(dir); err == nil {
+			if agentPkg, err := pkg.LoadAgentPkg(manifestPath); err == nil {
+				location = agentPkg.Registry
+			}
+		}
+	}
+	return registry.Open(location, opts)
+}
+
+// resolveLockfile solves the dependencies of agentPkg, preferring the
+// versions in preferred, such as those of an outdated lock, so the lock
+// changes as little as possible
+func resolveLockfile(agentPkg *pkg.AgentPkg, src packageSource, preferred map[string]string) (*pkg.Lockfile, error) {
+	lock := &pkg.Lockfile{
+		LockfileVersion: pkg.LockfileVersion,
+		Dependencies:    agentPkg.Dependencies,
+		DevDependencies: agentPkg.DevDependencies,
+		Packages:        make(map[string]*pkg.LockedPackage),
+	}
+	if len(agentPkg.AllDependencies()) == 0 {
+		return lock, nil
+	}
+	if src == nil {
+		return nil, fmt.Errorf("cannot resolve dependencies: no package registry is configured")
+	}
+
+	solver := &pkg.Solver{Source: src, Preferred: preferred}
+	resolved, err := solver.Solve(agentPkg)
+	var notCached *registry.NotCachedError
+	if errors.As(err, &notCached) {
+		missing := notCachedPackages(agentPkg, src, preferred)
+		return nil, fmt.Errorf("%w: %d %s not in the offline cache: %s\n%s", registry.ErrOffline, len(missing),
+			plural(len(missing), "package is", "packages are"), strings.Join(missing, ", "), offlineHint)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range resolved {
+		digest, err := src.Digest(r.Name, r.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get digest of %s@%s: %w", r.Name, r.Version, err)
+		}
+		lock.Packages[r.Name] = &pkg.LockedPackage{
+			Version:      r.Version,
+			Registry:     src.Location(),
+			Digest:       digest,
+			Dependencies: r.Dependencies,
+		}
+	}
+	return lock, nil
+}
+
+// InstallPackage adds the package ref, written name[@range], to the
+// dependencies of the project in the current directory, or of the global
+// prefix with opts.Global, and installs it. Without a range or
+// opts.Version the latest version is required with a caret range.
+func InstallPackage(ref string, opts InstallOptions) error {
+	dir, err := installDir(opts)
+	if err != nil {
+		return err
+	}
+	reg, err := openRegistry(dir, opts.Registry, registry.Options{Token: opts.Token, Network: opts.Network})
+	if err != nil {
+		return err
+	}
+	name, err := installPackage(dir, ref, registry.NewSource(reg), opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Package %s installed successfully\n", name)
+	return nil
+}
+
+func installPackage(dir, ref string, src packageSource, opts InstallOptions) (string, error) {
+	name, constraint := ref, ""
+	if i := strings.LastIndex(ref, "@"); i > 0 {
+		name, constraint = ref[:i], ref[i+1:]
+	}
+	if err := pkg.ValidateName(name); err != nil {
+		return "", err
+	}
+	pin := opts.Version != "" && opts.Version != "latest"
+	if pin {
+		if constraint != "" {
+			return "", fmt.Errorf("%s already gives a version range; do not also pass --version", ref)
+		}
+		if _, err := pkg.ParseConstraint(opts.Version); err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if constraint != "" {
+		if _, err := pkg.ParseConstraint(constraint); err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	manifestPath, err := pkg.FindAgentPkg(dir)
+	if err != nil {
+		return "", err
+	}
+	agentPkg, err := pkg.LoadAgentPkg(manifestPath)
+	if err != nil {
+		return "", err
+	}
+	if opts.FrozenLockfile {
+		return "", fmt.Errorf("--frozen-lockfile cannot be used when adding a package; it changes %s", pkg.LockfileName)
+	}
+	if constraint == "" {
+		versions, err := src.Versions(name)
+		if err != nil {
+			return "", err
+		}
+		required := "latest"
+		if pin {
+			required = opts.Version
+		}
+		version, err := pkg.ResolveVersion(required, versions)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+		constraint = version
+		if !pin {
+			constraint = "^" + version
+		}
+	}
+
+	// A package is in one section only; installing it again with or
+	// without --dev moves it.
+	section, other := pkg.DependencySections[0], pkg.DependencySections[1]
+	if opts.Dev {
+		section, other = other, section
+	}
+	edits := []pkg.DependencyEdit{
+		{Section: section, Name: name, Constraint: constraint},
+		{Section: other, Name: name},
+	}
+
+	// Resolve the manifest as it will be before writing it, so a range the
+	// registry cannot satisfy leaves agentpkg.yaml as it was.
+	lockPath := filepath.Join(dir, pkg.LockfileName)
+	previous, err := pkg.LoadLockfile(lockPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	var preferred map[string]string
+	if previous != nil {
+		preferred = previous.Preferred()
+		delete(preferred, name)
+	}
+	lock, err := resolveLockfile(agentPkg.WithDependencyEdits(edits...), src, preferred)
+	if err != nil {
+		return "", err
+	}
+
+	if err := pkg.EditDependencies(manifestPath, edits...); err != nil {
+		return "", err
+	}
+	fmt.Printf("Added %s %s to %s in %s\n", name, constraint, section, filepath.Base(manifestPath))
+	if err := lock.Save(lockPath); err != nil {
+		return "", err
+	}
+	fmt.Printf("Wrote %s\n", pkg.LockfileName)
+	return name, installLocked(dir, lock, src, opts)
+}
+
+// PublishOptions controls how a package is published
+type PublishOptions struct {
+	DryRun  bool
+	Private bool
+	// Registry is the location of the registry to publish to; empty
+	// selects the default registry
+	Registry string
+	// Token authenticates with HTTP registries
+	Token string
+	// Network is the network mode; publishing to an HTTP registry fails
+	// with registry.ErrOffline in Offline mode
+	Network registry.NetworkMode
+}
+
 // PublishPackage publishes a package to the registry
-func PublishPackage(dryRun, private bool) error {
-	if dryRun {
-		fmt.Println("🧪 Dry run: Would publish package to registry")
-		return nil
+func PublishPackage(opts PublishOptions) error {
+	return publishPackage(".", opts)
+}
+
+func publishPackage(dir string, opts PublishOptions) error {
+	agentPkg, err := loadPackage(dir)
+	if err != nil {
+		return err
 	}
-	
+
+	files, err := archive.Files(dir)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if _, err := archive.Pack(&buf, dir, files); err != nil {
+		return err
+	}
+
 	visibility := "public"
-	if private {
+	if opts.Private {
 		visibility = "private"
 	}
-	
+
+	if opts.DryRun {
+		fmt.Printf("🧪 Dry run: Would publish %s package %s@%s to registry (%d files, %d bytes)\n",
+			visibility, agentPkg.Name, agentPkg.Version, len(files), buf.Len())
+		return nil
+	}
+
+	reg, err := openRegistry(dir, opts.Registry, registry.Options{Token: opts.Token, Network: opts.Network})
+	if err != nil {
+		return err
+	}
+
 	fmt.Printf("Publishing %s package to registry...\n", visibility)
-	// TODO: Implement actual package publishing logic
-	fmt.Println("✅ Package published successfully")
+	info, err := reg.Publish(&registry.VersionInfo{
+		Name:         agentPkg.Name,
+		Version:      agentPkg.Version,
+		Description:  agentPkg.Description,
+		Dependencies: agentPkg.Dependencies,
+		Private:      opts.Private,
+	}, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to publish %s@%s: %w", agentPkg.Name, agentPkg.Version, err)
+	}
+
+	fmt.Printf("✅ Package %s@%s published successfully to %s (%s)\n", info.Name, info.Version, reg.Location(), info.Digest)
 	return nil
 }
 
+// BuildOptions controls how a package is built
+type BuildOptions struct {
+	Verbose bool
+	// OutputDir is the directory the archive and its metadata are written to
+	OutputDir string
+	// VerifyReproducible builds the package a second time and fails if the
+	// two archives differ
+	VerifyReproducible bool
+	// Watch keeps rebuilding the package as its files change until
+	// interrupted
+	Watch bool
+	// ListFiles prints the files that would be packed instead of building
+	ListFiles bool
+}
+
 // BuildPackage builds and validates the current package
-func BuildPackage(verbose bool, outputDir string) error {
-	if verbose {
+func BuildPackage(opts BuildOptions) error {
+	if opts.ListFiles {
+		return listPackageFiles(".", opts)
+	}
+	if opts.Watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return watchPackage(ctx, ".", opts, watchDebounce)
+	}
+	return buildPackage(".", opts)
+}
+
+func buildPackage(dir string, opts BuildOptions) error {
+	if opts.Verbose {
 		fmt.Println("Building package with verbose output...")
-		fmt.Printf("Output directory: %s\n", outputDir)
+		fmt.Printf("Output directory: %s\n", opts.OutputDir)
+	}
+	
+	fmt.Println("Validating package structure...")
+	agentPkg, err := loadPackage(dir)
+	if err != nil {
+		return err
 	}
 	
 	// Create output directory if it doesn't exist
+	outputDir := buildOutputDir(dir, opts)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 	
-	fmt.Println("Validating package structure...")
-	fmt.Println("Compiling package...")
-	fmt.Println("Running tests...")
-	fmt.Printf("✅ Package built successfully in %s\n", outputDir)
+	fmt.Println("Packing package...")
+	meta, err := archive.Build(dir, outputDir, agentPkg)
+	if err != nil {
+		return err
+	}
+	if opts.Verbose {
+		for _, f := range meta.Files {
+			fmt.Printf("  %s (%d bytes)\n", f.Path, f.Size)
+		}
+	}
+	
+	if opts.VerifyReproducible {
+		fmt.Println("Verifying the build is reproducible...")
+		if err := verifyReproducible(dir, agentPkg, meta); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Build is reproducible (%s)\n", meta.Digest)
+	}
+	
+	fmt.Printf("✅ Package built successfully: %s (%d files, %d bytes, %s)\n",
+		filepath.Join(opts.OutputDir, meta.Archive), len(meta.Files), meta.Size, meta.Digest)
+	return nil
+}
+
+// listPackageFiles prints every file a build of the package in dir would
+// pack, with its size, followed by the total
+func listPackageFiles(dir string, opts BuildOptions) error {
+	agentPkg, err := loadPackage(dir)
+	if err != nil {
+		return err
+	}
+	files, err := archive.BuildFiles(dir, buildOutputDir(dir, opts))
+	if err != nil {
+		return err
+	}
+	
+	fmt.Printf("📦 Files in %s@%s:\n", agentPkg.Name, agentPkg.Version)
+	var total int64
+	for _, name := range files {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", name, err)
+		}
+		total += info.Size()
+		fmt.Printf("  %10d  %s\n", info.Size(), name)
+	}
+	fmt.Printf("Total: %d files, %d bytes\n", len(files), total)
 	return nil
 }
+
+// loadPackage finds, loads and validates the manifest of the package in
+// dir. Warnings are printed; errors fail with every problem listed.
+func loadPackage(dir string) (*pkg.AgentPkg, error) {
+	agentPkg, problems, err := checkPackage(dir)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.HasErrors(problems) {
+		return nil, &pkg.ValidationError{Problems: problems}
+	}
+	for _, p := range problems {
+		fmt.Printf("⚠️  %s\n", p)
+	}
+	return agentPkg, nil
+}
+
+// buildOutputDir returns the output directory of a build of the package in
+// dir; a relative OutputDir is taken relative to the package
+func buildOutputDir(dir string, opts BuildOptions) string {
+	if filepath.IsAbs(opts.OutputDir) {
+		return opts.OutputDir
+	}
+	return filepath.Join(dir, opts.OutputDir)
+}
+
+// verifyReproducible rebuilds the package in dir into a temporary directory
+// and compares the result with the first build, listing every file that
+// differs when the digests do not match.
+func verifyReproducible(dir string, agentPkg *pkg.AgentPkg, first *archive.Metadata) error {
+	tmp, err := os.MkdirTemp("", "agenthub-build-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	
+	second, err := archive.Build(dir, tmp, agentPkg)
+	if err != nil {
+		return err
+	}
+	if second.Digest == first.Digest {
+		return nil
+	}
+	
+	var diffs []string
+	firstFiles := make(map[string]archive.FileEntry)
+	for _, f := range first.Files {
+		firstFiles[f.Path] = f
+	}
+	for _, f := range second.Files {
+		prev, ok := firstFiles[f.Path]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("  %s: only in second build", f.Path))
+		case prev.Digest != f.Digest:
+			diffs = append(diffs, fmt.Sprintf("  %s: %s != %s", f.Path, prev.Digest, f.Digest))
+		}
+		delete(firstFiles, f.Path)
+	}
+	for _, f := range first.Files {
+		if _, ok := firstFiles[f.Path]; ok {
+			diffs = append(diffs, fmt.Sprintf("  %s: only in first build", f.Path))
+		}
+	}
+	if len(diffs) == 0 {
+		diffs = append(diffs, "  file contents match; the archives differ in metadata or compression")
+	}
+	return fmt.Errorf("build is not reproducible: %s != %s\n%s", first.Digest, second.Digest, strings.Join(diffs, "\n"))
+}
+
+// ServeOptions controls the registry server
+type ServeOptions struct {
+	// Addr is the TCP address to listen on
+	Addr string
+	// Storage is the directory packages are stored in
+	Storage string
+	// Tokens are the bearer tokens accepted for publishing and for
+	// reading private packages
+	Tokens []string
+}
+
+// ServeRegistry serves a registry over HTTP from a local storage directory
+func ServeRegistry(opts ServeOptions) error {
+	if opts.Storage == "" {
+		var err error
+		if opts.Storage, err = registry.DefaultLocation(); err != nil {
+			return err
+		}
+	}
+	local, err := registry.NewLocal(opts.Storage)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(local.Location(), 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	fmt.Printf("📦 Serving registry from %s on %s\n", local.Location(), opts.Addr)
+	if len(opts.Tokens) == 0 {
+		fmt.Println("⚠️  No tokens configured: publishing is disabled and private packages are hidden")
+	}
+
+	srv := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           registry.NewServer(local, registry.ServerOptions{Tokens: opts.Tokens}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return srv.ListenAndServe()
+}
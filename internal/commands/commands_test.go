@@ -1,11 +1,25 @@
 package commands
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"agenthub/internal/archive"
+	"agenthub/internal/registry"
+	"agenthub/pkg"
 )
 
 func TestInitProject(t *testing.T) {
@@ -15,7 +29,7 @@ func TestInitProject(t *testing.T) {
 	
 	os.Chdir(tempDir)
 	
-	err := InitProject("test-project")
+	err := InitProject("test-project", InitOptions{})
 	assert.NoError(t, err)
 	
 	// Verify project directory structure
@@ -36,6 +50,63 @@ func TestInitProject(t *testing.T) {
 	}
 }
 
+func TestInitProjectInvalidRegistry(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	
+	os.Chdir(tempDir)
+	
+	err := InitProject("test-project", InitOptions{Registry: "ftp://registry.example.com"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported registry location")
+}
+
+func TestInitProjectTemplate(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	
+	os.Chdir(tempDir)
+	
+	err := InitProject("my-tool", InitOptions{Template: "tool", Author: "Ada"})
+	require.NoError(t, err)
+	
+	agentPkg, err := loadPackage(filepath.Join(tempDir, "my-tool"))
+	require.NoError(t, err)
+	assert.Equal(t, "my-tool", agentPkg.Name)
+	assert.Equal(t, "Ada", agentPkg.Author)
+	assert.FileExists(t, filepath.Join(tempDir, "my-tool", "tools", "main.py"))
+	assert.FileExists(t, filepath.Join(tempDir, "my-tool", "tests", "tool_test.yaml"))
+}
+
+func TestInitProjectRegistryTemplate(t *testing.T) {
+	registryDir := filepath.Join(t.TempDir(), "registry")
+	src := t.TempDir()
+	writeManifest(t, src, "name: team-template\nversion: 1.0.0\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "template", "prompts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "template", "agentpkg.yaml"),
+		[]byte("name: \"{{agenthub.name}}\"\nversion: 0.1.0\nauthor: \"{{agenthub.author}}\"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "template", "prompts", "system.md"),
+		[]byte("You are {{agenthub.name}}. Answer {{question}}.\n"), 0644))
+	require.NoError(t, publishPackage(src, PublishOptions{Registry: registryDir}))
+	
+	project := filepath.Join(t.TempDir(), "support-bot")
+	err := InitProject(project, InitOptions{Registry: registryDir, Template: "team-template@^1.0.0", Author: "Ada"})
+	require.NoError(t, err)
+	
+	agentPkg, err := loadPackage(project)
+	require.NoError(t, err)
+	assert.Equal(t, "support-bot", agentPkg.Name)
+	prompt, err := os.ReadFile(filepath.Join(project, "prompts", "system.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "You are support-bot. Answer {{question}}.\n", string(prompt))
+	
+	err = InitProject(filepath.Join(t.TempDir(), "other"), InitOptions{Registry: registryDir, Template: "no-such-template"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a built-in template")
+}
+
 func TestInitProjectExistingDirectory(t *testing.T) {
 	tempDir := t.TempDir()
 	originalDir, _ := os.Getwd()
@@ -44,37 +115,396 @@ func TestInitProjectExistingDirectory(t *testing.T) {
 	os.Chdir(tempDir)
 	
 	// Create project twice
-	err := InitProject("duplicate-project")
+	err := InitProject("duplicate-project", InitOptions{})
+	assert.NoError(t, err)
+	
+	// A second init refuses to touch the now non-empty directory
+	err = InitProject("duplicate-project", InitOptions{Version: "2.0.0"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not empty")
+	
+	err = InitProject("duplicate-project", InitOptions{Version: "2.0.0", Force: true})
 	assert.NoError(t, err)
+	agentPkg, err := loadPackage("duplicate-project")
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", agentPkg.Version)
+	
+	// An existing empty directory is fine
+	require.NoError(t, os.Mkdir("empty-project", 0755))
+	assert.NoError(t, InitProject("empty-project", InitOptions{}))
+}
+
+func TestInitProjectWritesManifest(t *testing.T) {
+	registryDir := t.TempDir()
+	project := filepath.Join(t.TempDir(), "support-bot")
 	
-	err = InitProject("duplicate-project")
-	assert.NoError(t, err) // Should not fail if directory already exists
+	err := InitProject(project, InitOptions{
+		Version:     "1.0.0",
+		Description: "Answers support tickets",
+		Author:      "Ada <ada@example.com>",
+		Registry:    registryDir,
+	})
+	require.NoError(t, err)
+	
+	agentPkg, err := loadPackage(project)
+	require.NoError(t, err)
+	assert.Equal(t, &pkg.AgentPkg{
+		Name:        "support-bot",
+		Version:     "1.0.0",
+		Description: "Answers support tickets",
+		Author:      "Ada <ada@example.com>",
+		Registry:    registryDir,
+	}, agentPkg)
+	assert.FileExists(t, filepath.Join(project, archive.IgnoreFileName))
+	
+	// The fresh project builds, and publishes to the registry in its manifest
+	require.NoError(t, buildPackage(project, BuildOptions{OutputDir: "dist"}))
+	require.NoError(t, publishPackage(project, PublishOptions{}))
+	reg, err := registry.NewLocal(registryDir)
+	require.NoError(t, err)
+	_, err = reg.Package("support-bot")
+	assert.NoError(t, err)
+}
+
+func TestInitProjectInvalidVersion(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "bot")
+	err := InitProject(project, InitOptions{Name: "bot", Version: "one"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid semantic version")
+	assert.NoDirExists(t, project)
+}
+
+// fakeSource is an in-memory packageSource for install tests
+type fakeSource map[string]map[string]map[string]string
+
+func (f fakeSource) Versions(name string) ([]string, error) {
+	var versions []string
+	for v := range f[name] {
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("package %s not found", name)
+	}
+	return versions, nil
+}
+
+func (f fakeSource) Dependencies(name, version string) (map[string]string, error) {
+	return f[name][version], nil
+}
+
+func (f fakeSource) Location() string {
+	return "memory"
+}
+
+func (f fakeSource) Digest(name, version string) (string, error) {
+	sum := sha256.Sum256(fakeArchive(name, version))
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func (f fakeSource) Fetch(name, version string) (io.ReadCloser, error) {
+	if _, ok := f[name][version]; !ok {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, registry.ErrNotFound)
+	}
+	return io.NopCloser(bytes.NewReader(fakeArchive(name, version))), nil
+}
+
+// fakeArchive returns a package archive holding just a manifest
+func fakeArchive(name, version string) []byte {
+	manifest := []byte(fmt.Sprintf("name: %s\nversion: %s\n", name, version))
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "agentpkg.yaml", Mode: 0644, Size: int64(len(manifest))})
+	tw.Write(manifest)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func mustDigest(t *testing.T, src fakeSource, name, version string) string {
+	t.Helper()
+	digest, err := src.Digest(name, version)
+	require.NoError(t, err)
+	return digest
+}
+
+func writeManifest(t *testing.T, dir, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "agentpkg.yaml"), []byte(content), 0644))
 }
 
 func TestInstallAll(t *testing.T) {
-	err := InstallAll()
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	
+	os.Chdir(tempDir)
+	writeManifest(t, tempDir, "name: my-app\nversion: 1.0.0\n")
+	
+	err := InstallAll(InstallOptions{})
 	assert.NoError(t, err)
-	// Since this is a stub implementation, we just verify it doesn't error
+	assert.FileExists(t, filepath.Join(tempDir, pkg.LockfileName))
+}
+
+func TestInstallAllMissingManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	
+	os.Chdir(tempDir)
+	
+	err := InstallAll(InstallOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no agentpkg.yaml found")
+}
+
+func TestInstallAllWritesLockfile(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n")
+	src := fakeSource{
+		"web-search": {"2.0.0": {"http-tool": "~1.0.0"}, "2.1.0": {"http-tool": "~1.0.0"}},
+		"http-tool":  {"1.0.0": nil, "1.0.3": nil, "1.1.0": nil},
+	}
+	opts := InstallOptions{Store: t.TempDir()}
+	
+	require.NoError(t, installAll(dir, src, opts))
+	
+	lock, err := pkg.LoadLockfile(filepath.Join(dir, pkg.LockfileName))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"web-search": "^2.0.0"}, lock.Dependencies)
+	assert.Equal(t, &pkg.LockedPackage{
+		Version:      "2.1.0",
+		Registry:     "memory",
+		Digest:       mustDigest(t, src, "web-search", "2.1.0"),
+		Dependencies: map[string]string{"http-tool": "~1.0.0"},
+	}, lock.Packages["web-search"])
+	assert.Equal(t, "1.0.3", lock.Packages["http-tool"].Version)
+	
+	// A second install with the same manifest produces an identical lock.
+	first, err := os.ReadFile(filepath.Join(dir, pkg.LockfileName))
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(dir, pkg.LockfileName)))
+	require.NoError(t, installAll(dir, src, opts))
+	second, err := os.ReadFile(filepath.Join(dir, pkg.LockfileName))
+	require.NoError(t, err)
+	assert.Equal(t, string(first), string(second))
+}
+
+func TestInstallAllReusesLockfile(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n")
+	src := fakeSource{"web-search": {"2.0.0": nil}}
+	opts := InstallOptions{Store: t.TempDir()}
+	require.NoError(t, installAll(dir, src, opts))
+	
+	// A newer release does not move an install that is already locked, and
+	// no registry is needed to install from the lock and the store.
+	src["web-search"]["2.1.0"] = nil
+	require.NoError(t, installAll(dir, nil, opts))
+	lock, err := pkg.LoadLockfile(filepath.Join(dir, pkg.LockfileName))
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", lock.Packages["web-search"].Version)
+	
+	// Changing the manifest re-resolves, keeping locked versions that still fit.
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n  http-tool: ^1.0.0\n")
+	src["http-tool"] = map[string]map[string]string{"1.0.0": nil}
+	require.NoError(t, installAll(dir, src, opts))
+	lock, err = pkg.LoadLockfile(filepath.Join(dir, pkg.LockfileName))
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", lock.Packages["web-search"].Version)
+	assert.Equal(t, "1.0.0", lock.Packages["http-tool"].Version)
+}
+
+func TestInstallAllFrozenLockfile(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n")
+	src := fakeSource{"web-search": {"2.0.0": nil, "3.0.0": nil}}
+	store := t.TempDir()
+	
+	err := installAll(dir, src, InstallOptions{FrozenLockfile: true, Store: store})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires an existing agenthub.lock")
+	
+	require.NoError(t, installAll(dir, src, InstallOptions{Store: store}))
+	require.NoError(t, installAll(dir, src, InstallOptions{FrozenLockfile: true, Store: store}))
+	
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^3.0.0\n")
+	lockBefore, err := os.ReadFile(filepath.Join(dir, pkg.LockfileName))
+	require.NoError(t, err)
+	err = installAll(dir, src, InstallOptions{FrozenLockfile: true, Store: store})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "web-search is ^3.0.0 in the manifest but ^2.0.0 in the lockfile")
+	lockAfter, err := os.ReadFile(filepath.Join(dir, pkg.LockfileName))
+	require.NoError(t, err)
+	assert.Equal(t, lockBefore, lockAfter, "a frozen install never rewrites the lock")
+}
+
+// uncachedSource is a fakeSource whose offline cache lacks the metadata
+// of some packages
+type uncachedSource struct {
+	fakeSource
+	missing map[string]bool
+}
+
+func (s uncachedSource) Versions(name string) ([]string, error) {
+	if s.missing[name] {
+		return nil, &registry.NotCachedError{Name: name}
+	}
+	return s.fakeSource.Versions(name)
+}
+
+func TestInstallAllOfflineNamesEveryMissingPackage(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n  summarizer: ^1.0.0\n")
+	src := uncachedSource{
+		fakeSource: fakeSource{"web-search": {"2.0.0": {"http-tool": "^1.0.0"}}},
+		missing:    map[string]bool{"http-tool": true, "summarizer": true},
+	}
+	
+	err := installAll(dir, src, InstallOptions{Store: t.TempDir()})
+	assert.ErrorIs(t, err, registry.ErrOffline)
+	assert.ErrorContains(t, err, "2 packages are not in the offline cache: http-tool, summarizer\n")
+	assert.ErrorContains(t, err, "without --offline")
+}
+
+func TestInstallAllWithoutRegistry(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n")
+	
+	err := installAll(dir, nil, InstallOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no package registry is configured")
 }
 
 func TestInstallPackage(t *testing.T) {
+	src := fakeSource{
+		"web-search": {"1.0.0": nil, "1.2.0": {"http-tool": "^1.0.0"}, "2.0.0-beta.1": nil},
+		"http-tool":  {"1.0.0": nil},
+		"@team/tool": {"0.3.0": nil, "0.4.0": nil},
+	}
+	
 	testCases := []struct {
-		name        string
-		packageName string
+		name       string
+		ref        string
+		dependency string
+		constraint string
+		version    string
 	}{
-		{"simple package", "simple-package"},
-		{"scoped package", "@scope/package"},
-		{"package with version", "package@1.0.0"},
+		{"latest version", "web-search", "web-search", "^1.2.0", "1.2.0"},
+		{"scoped package", "@team/tool", "@team/tool", "^0.4.0", "0.4.0"},
+		{"package with range", "web-search@~1.0.0", "web-search", "~1.0.0", "1.0.0"},
 	}
 	
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := InstallPackage(tc.packageName)
-			assert.NoError(t, err)
+			dir := t.TempDir()
+			writeManifest(t, dir, "name: my-app\nversion: 1.0.0\n")
+			
+			name, err := installPackage(dir, tc.ref, src, InstallOptions{Store: t.TempDir()})
+			require.NoError(t, err)
+			assert.Equal(t, tc.dependency, name)
+			
+			agentPkg, err := pkg.LoadAgentPkg(filepath.Join(dir, "agentpkg.yaml"))
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{tc.dependency: tc.constraint}, agentPkg.Dependencies)
+			lock, err := pkg.LoadLockfile(filepath.Join(dir, pkg.LockfileName))
+			require.NoError(t, err)
+			assert.Equal(t, tc.version, lock.Packages[tc.dependency].Version)
+			assert.FileExists(t, filepath.Join(dir, ModulesDir, filepath.FromSlash(tc.dependency), "agentpkg.yaml"))
 		})
 	}
 }
 
+func TestInstallPackageVersionAndDev(t *testing.T) {
+	src := fakeSource{
+		"web-search": {"1.0.0": nil, "1.2.0": nil, "1.2.3": nil, "2.0.0": nil},
+		"eval-kit":   {"0.3.0": nil},
+	}
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\n")
+	opts := InstallOptions{Store: t.TempDir()}
+	load := func() (*pkg.AgentPkg, *pkg.Lockfile) {
+		agentPkg, err := pkg.LoadAgentPkg(filepath.Join(dir, "agentpkg.yaml"))
+		require.NoError(t, err)
+		lock, err := pkg.LoadLockfile(filepath.Join(dir, pkg.LockfileName))
+		require.NoError(t, err)
+		return agentPkg, lock
+	}
+
+	// --version pins the version its range resolves to.
+	opts.Version = "~1.2.0"
+	_, err := installPackage(dir, "web-search", src, opts)
+	require.NoError(t, err)
+	agentPkg, lock := load()
+	assert.Equal(t, map[string]string{"web-search": "1.2.3"}, agentPkg.Dependencies)
+	assert.Equal(t, "1.2.3", lock.Packages["web-search"].Version)
+
+	_, err = installPackage(dir, "web-search@^2.0.0", src, opts)
+	assert.ErrorContains(t, err, "do not also pass --version")
+	opts.Version = "^3.0.0"
+	_, err = installPackage(dir, "web-search", src, opts)
+	assert.Error(t, err)
+
+	opts.Version, opts.Dev = "latest", true
+	_, err = installPackage(dir, "eval-kit", src, opts)
+	require.NoError(t, err)
+	agentPkg, lock = load()
+	assert.Equal(t, map[string]string{"eval-kit": "^0.3.0"}, agentPkg.DevDependencies)
+	assert.Equal(t, map[string]string{"eval-kit": "^0.3.0"}, lock.DevDependencies)
+	assert.Equal(t, "0.3.0", lock.Packages["eval-kit"].Version)
+	assert.FileExists(t, filepath.Join(dir, ModulesDir, "eval-kit", "agentpkg.yaml"))
+
+	// Installing again without --dev moves the package to dependencies.
+	opts.Dev = false
+	_, err = installPackage(dir, "eval-kit", src, opts)
+	require.NoError(t, err)
+	agentPkg, lock = load()
+	assert.Empty(t, agentPkg.DevDependencies)
+	assert.Equal(t, "^0.3.0", agentPkg.Dependencies["eval-kit"])
+	assert.Empty(t, lock.DevDependencies)
+}
+
+func TestInstallPackageKeepsManifestFormatting(t *testing.T) {
+	src := fakeSource{"web-search": {"2.1.0": nil}, "http-tool": {"1.0.0": nil}}
+	dir := t.TempDir()
+	writeManifest(t, dir, "# My app\nname: my-app\nversion: 1.0.0\n\ndependencies:\n  http-tool: 1.0.0 # pinned\n\nlicense: MIT\n")
+
+	_, err := installPackage(dir, "web-search", src, InstallOptions{Store: t.TempDir()})
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, "agentpkg.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "# My app\nname: my-app\nversion: 1.0.0\n\ndependencies:\n  http-tool: 1.0.0 # pinned\n  web-search: ^2.1.0\n\nlicense: MIT\n", string(data))
+}
+
+func TestInstallPackageErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\n")
+	src := fakeSource{"web-search": {"1.0.0": nil}}
+	
+	_, err := installPackage(dir, "Web Search", src, InstallOptions{Store: t.TempDir()})
+	assert.ErrorContains(t, err, "invalid package name")
+	_, err = installPackage(dir, "web-search@^x.y", src, InstallOptions{Store: t.TempDir()})
+	assert.Error(t, err)
+	_, err = installPackage(dir, "missing", src, InstallOptions{Store: t.TempDir()})
+	assert.ErrorContains(t, err, "package missing not found")
+	_, err = installPackage(t.TempDir(), "web-search", src, InstallOptions{Store: t.TempDir()})
+	assert.ErrorContains(t, err, "no agentpkg.yaml found")
+	
+	// A range nothing satisfies leaves the manifest as it was.
+	before, err := os.ReadFile(filepath.Join(dir, "agentpkg.yaml"))
+	require.NoError(t, err)
+	_, err = installPackage(dir, "web-search@^9.0.0", src, InstallOptions{Store: t.TempDir()})
+	assert.Error(t, err)
+	after, err := os.ReadFile(filepath.Join(dir, "agentpkg.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+	assert.NoFileExists(t, filepath.Join(dir, pkg.LockfileName))
+	require.NoError(t, installAll(dir, src, InstallOptions{Store: t.TempDir()}), "later installs still work")
+	
+	_, err = installPackage(dir, "web-search", src, InstallOptions{Store: t.TempDir(), FrozenLockfile: true})
+	assert.ErrorContains(t, err, "--frozen-lockfile cannot be used when adding a package")
+}
+
 func TestPublishPackage(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -89,18 +519,92 @@ func TestPublishPackage(t *testing.T) {
 	
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := PublishPackage(tc.dryRun, tc.private)
+			tempDir := t.TempDir()
+			originalDir, _ := os.Getwd()
+			defer os.Chdir(originalDir)
+			
+			os.Chdir(tempDir)
+			writeManifest(t, tempDir, "name: my-tool\nversion: 1.0.0\n")
+			registryDir := filepath.Join(tempDir, "registry")
+			
+			err := PublishPackage(PublishOptions{DryRun: tc.dryRun, Private: tc.private, Registry: registryDir})
 			assert.NoError(t, err)
+			
+			if tc.dryRun {
+				assert.NoDirExists(t, registryDir)
+				return
+			}
+			reg, err := registry.NewLocal(registryDir)
+			require.NoError(t, err)
+			info, err := reg.Package("my-tool")
+			require.NoError(t, err)
+			assert.Equal(t, tc.private, info.Versions["1.0.0"].Private)
 		})
 	}
 }
 
+func TestOfflineCommandsNeedingTheRegistry(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-tool\nversion: 1.0.0\n")
+	unreachable := "http://127.0.0.1:1"
+	
+	err := publishPackage(dir, PublishOptions{Registry: unreachable, Network: registry.Offline})
+	assert.ErrorIs(t, err, registry.ErrOffline)
+	
+	err = InitProject(filepath.Join(dir, "project"), InitOptions{Registry: unreachable, Template: "team-template", Network: registry.Offline})
+	assert.ErrorIs(t, err, registry.ErrOffline)
+}
+
+func TestPublishPackageMissingManifest(t *testing.T) {
+	err := publishPackage(t.TempDir(), PublishOptions{DryRun: true})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no agentpkg.yaml found")
+}
+
+func TestPublishThenInstall(t *testing.T) {
+	registryDir := filepath.Join(t.TempDir(), "registry")
+	
+	publish := func(manifest string) {
+		dir := t.TempDir()
+		writeManifest(t, dir, manifest)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "tools"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "tools", "tool.py"), []byte("print('hi')\n"), 0644))
+		require.NoError(t, publishPackage(dir, PublishOptions{Registry: registryDir}))
+	}
+	publish("name: http-tool\nversion: 1.0.0\n")
+	publish("name: http-tool\nversion: 1.1.0\n")
+	publish("name: web-search\nversion: 2.0.0\ndependencies:\n  http-tool: ~1.0.0\n")
+	
+	// Publishing the same version twice is rejected.
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: http-tool\nversion: 1.0.0\n")
+	err := publishPackage(dir, PublishOptions{Registry: registryDir})
+	assert.ErrorIs(t, err, registry.ErrVersionExists)
+	
+	project := t.TempDir()
+	writeManifest(t, project, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n")
+	reg, err := registry.NewLocal(registryDir)
+	require.NoError(t, err)
+	require.NoError(t, installAll(project, registry.NewSource(reg), InstallOptions{Store: t.TempDir()}))
+	
+	lock, err := pkg.LoadLockfile(filepath.Join(project, pkg.LockfileName))
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", lock.Packages["web-search"].Version)
+	assert.Equal(t, "1.0.0", lock.Packages["http-tool"].Version)
+	assert.Equal(t, reg.Location(), lock.Packages["http-tool"].Registry)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", lock.Packages["http-tool"].Digest)
+	assert.FileExists(t, filepath.Join(project, ModulesDir, "http-tool", "tools", "tool.py"))
+	assert.FileExists(t, filepath.Join(project, ModulesDir, "web-search", "agentpkg.yaml"))
+}
+
 func TestBuildPackage(t *testing.T) {
 	tempDir := t.TempDir()
 	originalDir, _ := os.Getwd()
 	defer os.Chdir(originalDir)
 	
 	os.Chdir(tempDir)
+	writeManifest(t, tempDir, "name: my-agent\nversion: 1.2.0\n")
 	
 	testCases := []struct {
 		name      string
@@ -114,18 +618,120 @@ func TestBuildPackage(t *testing.T) {
 	
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := BuildPackage(tc.verbose, tc.outputDir)
+			err := BuildPackage(BuildOptions{Verbose: tc.verbose, OutputDir: tc.outputDir})
 			assert.NoError(t, err)
 			
 			// Verify output directory was created
 			assert.DirExists(t, tc.outputDir)
+			assert.FileExists(t, filepath.Join(tc.outputDir, "my-agent-1.2.0.tgz"))
+			assert.FileExists(t, filepath.Join(tc.outputDir, "my-agent-1.2.0.json"))
 		})
 	}
 }
 
+func TestBuildPackageArchive(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: \"@team/my-agent\"\nversion: 1.2.0\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "prompts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prompts", "system.md"), []byte("You are helpful.\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "scratch.txt"), []byte("not packed\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, archive.IgnoreFileName), []byte("scratch.txt\n"), 0644))
+	
+	require.NoError(t, buildPackage(dir, BuildOptions{OutputDir: "dist"}))
+	
+	archivePath := filepath.Join(dir, "dist", "team-my-agent-1.2.0.tgz")
+	data, err := os.ReadFile(archivePath)
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+	
+	metaData, err := os.ReadFile(filepath.Join(dir, "dist", "team-my-agent-1.2.0.json"))
+	require.NoError(t, err)
+	var meta archive.Metadata
+	require.NoError(t, json.Unmarshal(metaData, &meta))
+	assert.Equal(t, "@team/my-agent", meta.Name)
+	assert.Equal(t, "1.2.0", meta.Version)
+	assert.Equal(t, "team-my-agent-1.2.0.tgz", meta.Archive)
+	assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), meta.Digest)
+	assert.Equal(t, int64(len(data)), meta.Size)
+	
+	var paths []string
+	for _, f := range meta.Files {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{archive.IgnoreFileName, "agentpkg.yaml", "prompts/system.md"}, paths)
+}
+
+func TestBuildPackageVerifyReproducible(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-agent\nversion: 1.2.0\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tools"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tools", "run.sh"), []byte("#!/bin/sh\n"), 0755))
+	
+	require.NoError(t, buildPackage(dir, BuildOptions{OutputDir: "dist", VerifyReproducible: true}))
+	first, err := os.ReadFile(filepath.Join(dir, "dist", "my-agent-1.2.0.tgz"))
+	require.NoError(t, err)
+	
+	// Touching a file changes nothing in the archive.
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "tools", "run.sh"), later, later))
+	require.NoError(t, buildPackage(dir, BuildOptions{OutputDir: "dist"}))
+	second, err := os.ReadFile(filepath.Join(dir, "dist", "my-agent-1.2.0.tgz"))
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestListPackageFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-agent\nversion: 1.2.0\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("SECRET=1\n"), 0644))
+	
+	require.NoError(t, listPackageFiles(dir, BuildOptions{OutputDir: "dist"}))
+	assert.NoDirExists(t, filepath.Join(dir, "dist"), "listing files does not build")
+	
+	// A custom output directory inside the package is never packed.
+	require.NoError(t, buildPackage(dir, BuildOptions{OutputDir: "out"}))
+	require.NoError(t, buildPackage(dir, BuildOptions{OutputDir: "out"}))
+	var meta archive.Metadata
+	data, err := os.ReadFile(filepath.Join(dir, "out", "my-agent-1.2.0.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &meta))
+	require.Len(t, meta.Files, 1)
+	assert.Equal(t, "agentpkg.yaml", meta.Files[0].Path)
+}
+
+func TestBuildPackageInvalidManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "version: 1.0.0\n")
+	
+	err := buildPackage(dir, BuildOptions{OutputDir: "dist"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "name is required")
+	assert.NoDirExists(t, filepath.Join(dir, "dist"))
+}
+
+func TestBuildPackageReportsAllProblems(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: My-Agent\nversion: 1\ndependencies:\n  tool: \"^1.x.y\"\n")
+	
+	err := buildPackage(dir, BuildOptions{OutputDir: "dist"})
+	require.Error(t, err)
+	var verr *pkg.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Contains(t, err.Error(), "invalid manifest: 3 errors")
+	assert.Contains(t, err.Error(), "agentpkg.yaml:1:7: error: name: ")
+	assert.Contains(t, err.Error(), "agentpkg.yaml:2:10: error: version: ")
+	assert.Contains(t, err.Error(), "agentpkg.yaml:4:9: error: dependencies.tool: ")
+	assert.Contains(t, err.Error(), "warning: license: ")
+}
+
 func TestBuildPackageInvalidPath(t *testing.T) {
-	// Test with invalid output directory path
-	err := BuildPackage(false, "/invalid/path/that/cannot/be/created")
+	// Test with an output directory that cannot be created because a
+	// regular file is in the way
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-agent\nversion: 1.2.0\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blocker"), nil, 0644))
+	
+	err := buildPackage(dir, BuildOptions{OutputDir: filepath.Join("blocker", "dist")})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create output directory")
 }
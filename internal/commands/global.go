@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"agenthub/internal/store"
+	"agenthub/pkg"
+)
+
+// GlobalBinDir is the directory of the global prefix that holds a command
+// for each entrypoint of a globally installed tool package.
+const GlobalBinDir = "bin"
+
+// globalManifestName is the name of the manifest created in a new global
+// prefix
+const globalManifestName = "agenthub-global"
+
+// shimMarker identifies the commands agenthub writes into GlobalBinDir, so
+// stale ones can be removed without touching anything else in it
+const shimMarker = "agenthub global shim"
+
+// DefaultGlobalDir returns the global prefix used when none is configured.
+func DefaultGlobalDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".agenthub", "global"), nil
+}
+
+// globalDir returns the global prefix selected by opts, creating it with an
+// empty manifest the first time it is used
+func globalDir(opts InstallOptions) (string, error) {
+	dir := opts.GlobalDir
+	if dir == "" {
+		var err error
+		if dir, err = DefaultGlobalDir(); err != nil {
+			return "", err
+		}
+	}
+	if _, err := pkg.FindAgentPkg(dir); err == nil {
+		return dir, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	manifest := &pkg.AgentPkg{
+		Name:        globalManifestName,
+		Version:     "0.0.0",
+		Description: "Packages installed with agenthub install --global",
+	}
+	if err := pkg.SaveAgentPkg(filepath.Join(dir, "agentpkg.yaml"), manifest); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// linkGlobalBins writes a command into the GlobalBinDir of the global
+// prefix dir for the entrypoint of each installed tool package, named after
+// the package without its scope, and removes commands of packages that are
+// gone. It returns the names of the commands.
+func linkGlobalBins(dir string, lock *pkg.Lockfile) ([]string, error) {
+	bin := filepath.Join(dir, GlobalBinDir)
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", bin, err)
+	}
+
+	shims := make(map[string]string)
+	var names []string
+	for _, name := range sortedNames(lock.Dependencies) {
+		module := filepath.Join(dir, ModulesDir, filepath.FromSlash(name))
+		manifestPath, err := pkg.FindAgentPkg(module)
+		if err != nil {
+			continue
+		}
+		installed, err := pkg.LoadAgentPkg(manifestPath)
+		if err != nil || installed.Tool == nil || installed.Tool.Entrypoint == "" {
+			continue
+		}
+		command := path.Base(name)
+		if other, ok := shims[command]; ok {
+			fmt.Printf("⚠️  %s also provides the command %s; keeping the one of %s\n", name, command, other)
+			continue
+		}
+		entry, err := store.Target(module, installed.Tool.Entrypoint)
+		if err != nil {
+			fmt.Printf("⚠️  %s: the entrypoint %s is not inside the package; no command is installed\n", name, installed.Tool.Entrypoint)
+			continue
+		}
+		if entry, err = filepath.Abs(entry); err != nil {
+			return nil, err
+		}
+		file := filepath.Join(bin, shimName(command))
+		if data, err := os.ReadFile(file); err == nil && !strings.Contains(string(data), shimMarker) {
+			fmt.Printf("⚠️  %s: %s is not an agenthub command; leaving it in place\n", name, file)
+			continue
+		}
+		if err := writeShim(file, entry); err != nil {
+			return nil, err
+		}
+		shims[command] = name
+		names = append(names, command)
+	}
+
+	entries, err := os.ReadDir(bin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", bin, err)
+	}
+	for _, e := range entries {
+		command := strings.TrimSuffix(e.Name(), ".cmd")
+		if _, ok := shims[command]; ok || e.IsDir() {
+			continue
+		}
+		file := filepath.Join(bin, e.Name())
+		if data, err := os.ReadFile(file); err == nil && strings.Contains(string(data), shimMarker) {
+			if err := os.Remove(file); err != nil {
+				return nil, fmt.Errorf("failed to remove %s: %w", file, err)
+			}
+		}
+	}
+	return names, nil
+}
+
+func shimName(command string) string {
+	if runtime.GOOS == "windows" {
+		return command + ".cmd"
+	}
+	return command
+}
+
+// interpreters maps the extensions of script entrypoints without a "#!"
+// line to the program that runs them
+var interpreters = map[string]string{".py": "python3", ".js": "node", ".mjs": "node", ".sh": "sh"}
+
+// interpreter returns the command that runs the script entry, from its
+// "#!" line or else its extension, or nil for a program run directly.
+// Running the interpreter lets scripts without a "#!" line or without the
+// executable bit work as commands too.
+func interpreter(entry string) []string {
+	if f, err := os.Open(entry); err == nil {
+		line, _ := bufio.NewReader(io.LimitReader(f, 256)).ReadString('\n')
+		f.Close()
+		rest, ok := strings.CutPrefix(line, "#!")
+		if fields := strings.Fields(rest); ok && len(fields) > 0 && runtime.GOOS != "windows" {
+			return fields
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(entry))
+	if ext == ".py" && runtime.GOOS == "windows" {
+		return []string{"python"}
+	}
+	if program, ok := interpreters[ext]; ok {
+		return []string{program}
+	}
+	return nil
+}
+
+// writeShim writes a command at file that runs entry, through its
+// interpreter, with its arguments
+func writeShim(file, entry string) error {
+	command := append(interpreter(entry), entry)
+	var script string
+	if runtime.GOOS == "windows" {
+		script = fmt.Sprintf("@echo off\r\nrem %s\r\n\"%s\" %%*\r\n", shimMarker, strings.Join(command, `" "`))
+	} else {
+		quoted := make([]string, len(command))
+		for i, arg := range command {
+			quoted[i] = shellQuote(arg)
+		}
+		script = fmt.Sprintf("#!/bin/sh\n# %s\nexec %s \"$@\"\n", shimMarker, strings.Join(quoted, " "))
+	}
+	if err := os.WriteFile(file, []byte(script), 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	// WriteFile keeps the mode of a file that already exists.
+	if err := os.Chmod(file, 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// onPath reports whether dir is in the PATH environment variable
+func onPath(dir string) bool {
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if p != "" && filepath.Clean(p) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"agenthub/pkg"
+)
+
+const toolManifest = `name: "%s"
+version: 1.0.0
+kind: tool
+tool:
+  entrypoint: tools/tool.py
+  input: {type: object}
+  output: {type: object}
+`
+
+func TestInstallPackageGlobal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shims are .cmd files on Windows")
+	}
+	registryDir := filepath.Join(t.TempDir(), "registry")
+	for _, name := range []string{"@team/web-search", "notes"} {
+		dir := t.TempDir()
+		manifest := toolManifest
+		if name == "notes" {
+			manifest = "name: \"%s\"\nversion: 1.0.0\n"
+		}
+		writeManifest(t, dir, fmt.Sprintf(manifest, name))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "tools"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "tools", "tool.py"), []byte("print('hi')\n"), 0755))
+		require.NoError(t, publishPackage(dir, PublishOptions{Registry: registryDir}))
+	}
+
+	global := filepath.Join(t.TempDir(), "global")
+	bin := filepath.Join(global, GlobalBinDir)
+	require.NoError(t, os.MkdirAll(bin, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "stale"), []byte("#!/bin/sh\n# "+shimMarker+"\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "mine"), []byte("#!/bin/sh\n"), 0755))
+
+	opts := InstallOptions{Registry: registryDir, Store: t.TempDir(), Global: true, GlobalDir: global}
+	require.NoError(t, InstallPackage("@team/web-search", opts))
+	require.NoError(t, InstallPackage("notes", opts))
+
+	agentPkg, err := pkg.LoadAgentPkg(filepath.Join(global, "agentpkg.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, globalManifestName, agentPkg.Name)
+	assert.Equal(t, map[string]string{"@team/web-search": "^1.0.0", "notes": "^1.0.0"}, agentPkg.Dependencies)
+	assert.FileExists(t, filepath.Join(global, pkg.LockfileName))
+
+	shim := filepath.Join(bin, "web-search")
+	info, err := os.Stat(shim)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	script, err := os.ReadFile(shim)
+	require.NoError(t, err)
+	entry := filepath.Join(global, ModulesDir, "@team", "web-search", "tools", "tool.py")
+	assert.Contains(t, string(script), "exec 'python3' '"+entry+"' \"$@\"")
+
+	// Only tool packages get a command, and only agenthub's own commands
+	// are removed.
+	assert.NoFileExists(t, filepath.Join(bin, "notes"))
+	assert.NoFileExists(t, filepath.Join(bin, "stale"))
+	assert.FileExists(t, filepath.Join(bin, "mine"))
+
+	opts.Dev = true
+	assert.ErrorContains(t, InstallPackage("notes", opts), "--dev cannot be used with --global")
+}
+
+func TestWriteShimRunsScriptsThroughInterpreter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shims are .cmd files on Windows")
+	}
+	dir := t.TempDir()
+	// A script without a "#!" line or the executable bit.
+	script := filepath.Join(dir, "run.sh")
+	require.NoError(t, os.WriteFile(script, []byte("echo hello \"$1\"\n"), 0644))
+	shim := filepath.Join(dir, "run")
+	require.NoError(t, writeShim(shim, script))
+	out, err := exec.Command(shim, "world").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world\n", string(out))
+
+	withShebang := filepath.Join(dir, "tool")
+	require.NoError(t, os.WriteFile(withShebang, []byte("#!/usr/bin/env python3 -u\nprint()\n"), 0644))
+	assert.Equal(t, []string{"/usr/bin/env", "python3", "-u"}, interpreter(withShebang))
+	assert.Equal(t, []string{"node"}, interpreter(filepath.Join(dir, "missing.js")))
+	assert.Nil(t, interpreter(filepath.Join(dir, "binary")))
+}
+
+func TestLinkGlobalBinsEntrypointOutsidePackage(t *testing.T) {
+	global := t.TempDir()
+	module := filepath.Join(global, ModulesDir, "evil")
+	require.NoError(t, os.MkdirAll(module, 0755))
+	manifest := strings.Replace(fmt.Sprintf(toolManifest, "evil"), "tools/tool.py", "../../../outside.sh", 1)
+	writeManifest(t, module, manifest)
+	lock := &pkg.Lockfile{Dependencies: map[string]string{"evil": "^1.0.0"}}
+
+	commands, err := linkGlobalBins(global, lock)
+	require.NoError(t, err)
+	assert.Empty(t, commands)
+	assert.NoFileExists(t, filepath.Join(global, GlobalBinDir, shimName("evil")))
+}
+
+func TestLinkGlobalBinsKeepsOtherCommands(t *testing.T) {
+	global := t.TempDir()
+	module := filepath.Join(global, ModulesDir, "tool")
+	require.NoError(t, os.MkdirAll(filepath.Join(module, "tools"), 0755))
+	writeManifest(t, module, fmt.Sprintf(toolManifest, "tool"))
+	require.NoError(t, os.WriteFile(filepath.Join(module, "tools", "tool.py"), []byte("print()\n"), 0644))
+	lock := &pkg.Lockfile{Dependencies: map[string]string{"tool": "^1.0.0"}}
+
+	bin := filepath.Join(global, GlobalBinDir)
+	require.NoError(t, os.MkdirAll(bin, 0755))
+	mine := filepath.Join(bin, shimName("tool"))
+	require.NoError(t, os.WriteFile(mine, []byte("my own tool\n"), 0755))
+
+	commands, err := linkGlobalBins(global, lock)
+	require.NoError(t, err)
+	assert.Empty(t, commands)
+	data, err := os.ReadFile(mine)
+	require.NoError(t, err)
+	assert.Equal(t, "my own tool\n", string(data))
+
+	// A command agenthub wrote is replaced.
+	require.NoError(t, os.WriteFile(mine, []byte("# "+shimMarker+"\n"), 0755))
+	commands, err = linkGlobalBins(global, lock)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tool"}, commands)
+	data, err = os.ReadFile(mine)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "tool.py")
+}
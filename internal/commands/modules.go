@@ -0,0 +1,252 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"agenthub/internal/registry"
+	"agenthub/internal/store"
+	"agenthub/pkg"
+)
+
+// ModulesDir is the directory of a project its dependencies are installed
+// into, one directory per package.
+const ModulesDir = "agent_modules"
+
+// modulesStateFile records, inside ModulesDir, the archive digest each
+// installed package was linked from, so unchanged packages are left alone
+const modulesStateFile = ".agenthub-modules.yaml"
+
+// offlineHint tells how to fill the offline cache
+const offlineHint = "run the command once without --offline to cache what is missing"
+
+// linkStats counts what linkModules did
+type linkStats struct {
+	Downloaded int
+	Linked     int
+	Unchanged  int
+	Removed    []string
+}
+
+// linkModules makes ModulesDir of the project in dir hold exactly the
+// packages in lock. Packages missing from st are fetched from src first;
+// packages no longer locked are removed.
+func linkModules(dir string, lock *pkg.Lockfile, src packageSource, st *store.Store) (*linkStats, error) {
+	modules := filepath.Join(dir, ModulesDir)
+	state, err := loadModulesState(modules)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check every name before anything is linked or removed.
+	targets := make(map[string]string, len(lock.Packages))
+	for name := range lock.Packages {
+		if targets[name], err = modulePath(modules, name); err != nil {
+			return nil, err
+		}
+	}
+
+	stats := &linkStats{}
+	var notCached []string
+	for _, name := range sortedNames(lock.Packages) {
+		locked := lock.Packages[name]
+		target := targets[name]
+		if state[name] == locked.Digest && st.Has(locked.Digest) {
+			if _, err := os.Stat(target); err == nil {
+				stats.Unchanged++
+				continue
+			}
+		}
+
+		if !st.Has(locked.Digest) {
+			err := fetchToStore(name, locked, src, st)
+			var notCachedErr *registry.NotCachedError
+			if errors.As(err, &notCachedErr) {
+				// Name every package the offline cache lacks at once.
+				notCached = append(notCached, name+"@"+locked.Version)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			stats.Downloaded++
+		}
+		if err := st.Link(locked.Digest, modules, name); err != nil {
+			return nil, err
+		}
+		state[name] = locked.Digest
+		stats.Linked++
+	}
+	if len(notCached) > 0 {
+		return nil, fmt.Errorf("%d %s not in the offline cache: %s\n%s", len(notCached),
+			plural(len(notCached), "package is", "packages are"), strings.Join(notCached, ", "), offlineHint)
+	}
+
+	for _, name := range sortedNames(state) {
+		if _, ok := lock.Packages[name]; ok {
+			continue
+		}
+		if err := removeModule(modules, name); err != nil {
+			return nil, err
+		}
+		delete(state, name)
+		stats.Removed = append(stats.Removed, name)
+	}
+
+	if err := saveModulesState(modules, state); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// fetchToStore downloads the archive of a locked package into st. The
+// registry must publish the digest pinned in the lock, and the archive
+// must have it, before anything is unpacked. Offline, an archive the cache
+// holds under the pinned digest needs no metadata.
+func fetchToStore(name string, locked *pkg.LockedPackage, src packageSource, st *store.Store) error {
+	if src == nil {
+		return fmt.Errorf("cannot download %s@%s: no package registry is configured", name, locked.Version)
+	}
+	published, err := src.Digest(name, locked.Version)
+	var notCached *registry.NotCachedError
+	if errors.As(err, &notCached) {
+		// Offline, the lock alone is enough for an archive the cache holds;
+		// storing it checks the archive has the pinned digest.
+		if cache, ok := src.(archiveCache); ok {
+			if rc, ok := cache.Archive(locked.Digest); ok {
+				defer rc.Close()
+				return addToStore(name, locked, rc, st)
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get digest of %s@%s: %w", name, locked.Version, err)
+	}
+	if published != locked.Digest {
+		return fmt.Errorf("integrity check failed for %s@%s: the registry publishes %s but %s pins %s",
+			name, locked.Version, published, pkg.LockfileName, locked.Digest)
+	}
+
+	rc, err := src.Fetch(name, locked.Version)
+	if err != nil {
+		return fmt.Errorf("failed to download %s@%s: %w", name, locked.Version, err)
+	}
+	defer rc.Close()
+	return addToStore(name, locked, rc, st)
+}
+
+// archiveCache is implemented by sources that keep archives by digest,
+// such as a registry.Source reading through a registry.Cache
+type archiveCache interface {
+	Archive(digest string) (io.ReadCloser, bool)
+}
+
+// addToStore unpacks the archive of a locked package into st, failing
+// unless it has the pinned digest
+func addToStore(name string, locked *pkg.LockedPackage, rc io.Reader, st *store.Store) error {
+	_, err := st.Add(rc, locked.Digest)
+	var digestErr *store.DigestError
+	if errors.As(err, &digestErr) {
+		return fmt.Errorf("integrity check failed for %s@%s: downloaded archive is %s, expected %s",
+			name, locked.Version, digestErr.Actual, digestErr.Expected)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store %s@%s: %w", name, locked.Version, err)
+	}
+	return nil
+}
+
+// modulePath returns the directory of package name in modules. Names come
+// from files anyone can commit, so a name that is not a package name, or
+// that store.Target refuses, is an error.
+func modulePath(modules, name string) (string, error) {
+	if err := pkg.ValidateName(name); err != nil {
+		return "", fmt.Errorf("refusing to install %q: %w", name, err)
+	}
+	target, err := store.Target(modules, name)
+	if err != nil {
+		return "", fmt.Errorf("refusing to install %q: %w", name, err)
+	}
+	return target, nil
+}
+
+// removeModule deletes an installed package, and its scope directory once
+// that is empty
+func removeModule(modules, name string) error {
+	target, err := modulePath(modules, name)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", name, err)
+	}
+	if scope, _, ok := strings.Cut(name, "/"); ok {
+		// Fails, harmlessly, while other packages of the scope remain.
+		os.Remove(filepath.Join(modules, scope))
+	}
+	return nil
+}
+
+func loadModulesState(modules string) (map[string]string, error) {
+	state := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(modules, modulesStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", modulesStateFile, err)
+	}
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		// A damaged state only costs relinking every package.
+		return make(map[string]string), nil
+	}
+	if state == nil {
+		state = make(map[string]string)
+	}
+	for name := range state {
+		// agenthub never records such a name; leave whatever it points at.
+		if pkg.ValidateName(name) != nil {
+			delete(state, name)
+		}
+	}
+	return state, nil
+}
+
+func saveModulesState(modules string, state map[string]string) error {
+	if len(state) == 0 {
+		err := os.Remove(filepath.Join(modules, modulesStateFile))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to update %s: %w", modulesStateFile, err)
+		}
+		// Leave a ModulesDir the user put other files in.
+		os.Remove(modules)
+		return nil
+	}
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", modulesStateFile, err)
+	}
+	if err := os.MkdirAll(modules, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", ModulesDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(modules, modulesStateFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to update %s: %w", modulesStateFile, err)
+	}
+	return nil
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
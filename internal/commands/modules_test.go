@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"agenthub/internal/registry"
+	"agenthub/internal/store"
+	"agenthub/pkg"
+)
+
+func lockOf(t *testing.T, src fakeSource, versions map[string]string) *pkg.Lockfile {
+	t.Helper()
+	lock := &pkg.Lockfile{LockfileVersion: pkg.LockfileVersion, Packages: make(map[string]*pkg.LockedPackage)}
+	for name, version := range versions {
+		lock.Packages[name] = &pkg.LockedPackage{Version: version, Registry: src.Location(), Digest: mustDigest(t, src, name, version)}
+	}
+	return lock
+}
+
+func TestLinkModules(t *testing.T) {
+	dir := t.TempDir()
+	st, err := store.Open(t.TempDir())
+	require.NoError(t, err)
+	src := fakeSource{
+		"web-search": {"1.0.0": nil, "1.1.0": nil},
+		"@team/tool": {"0.1.0": nil},
+	}
+
+	stats, err := linkModules(dir, lockOf(t, src, map[string]string{"web-search": "1.0.0", "@team/tool": "0.1.0"}), src, st)
+	require.NoError(t, err)
+	assert.Equal(t, &linkStats{Downloaded: 2, Linked: 2}, stats)
+	assert.FileExists(t, filepath.Join(dir, ModulesDir, "@team", "tool", "agentpkg.yaml"))
+
+	// A second project reuses the store without downloading.
+	other := t.TempDir()
+	stats, err = linkModules(other, lockOf(t, src, map[string]string{"web-search": "1.0.0"}), nil, st)
+	require.NoError(t, err)
+	assert.Equal(t, &linkStats{Linked: 1}, stats)
+
+	// Unchanged packages are left alone, removed ones relinked, and
+	// packages no longer locked pruned.
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, ModulesDir, "web-search")))
+	stats, err = linkModules(dir, lockOf(t, src, map[string]string{"web-search": "1.0.0", "@team/tool": "0.1.0"}), src, st)
+	require.NoError(t, err)
+	assert.Equal(t, &linkStats{Linked: 1, Unchanged: 1}, stats)
+
+	stats, err = linkModules(dir, lockOf(t, src, map[string]string{"web-search": "1.1.0"}), src, st)
+	require.NoError(t, err)
+	assert.Equal(t, &linkStats{Downloaded: 1, Linked: 1, Removed: []string{"@team/tool"}}, stats)
+	assert.NoDirExists(t, filepath.Join(dir, ModulesDir, "@team"))
+	data, err := os.ReadFile(filepath.Join(dir, ModulesDir, "web-search", "agentpkg.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "version: 1.1.0")
+
+	_, err = linkModules(dir, lockOf(t, src, nil), src, st)
+	require.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(dir, ModulesDir))
+}
+
+func TestLinkModulesErrors(t *testing.T) {
+	dir := t.TempDir()
+	st, err := store.Open(t.TempDir())
+	require.NoError(t, err)
+	src := fakeSource{"web-search": {"1.0.0": nil}}
+
+	_, err = linkModules(dir, lockOf(t, src, map[string]string{"web-search": "1.0.0"}), nil, st)
+	assert.ErrorContains(t, err, "cannot download web-search@1.0.0: no package registry is configured")
+
+	// The lock pins a digest the registry does not publish.
+	zero := "sha256:" + strings.Repeat("0", 64)
+	published := mustDigest(t, src, "web-search", "1.0.0")
+	lock := lockOf(t, src, map[string]string{"web-search": "1.0.0"})
+	lock.Packages["web-search"].Digest = zero
+	_, err = linkModules(dir, lock, src, st)
+	assert.EqualError(t, err, "integrity check failed for web-search@1.0.0: the registry publishes "+
+		published+" but agenthub.lock pins "+zero)
+
+	// The registry serves an archive that does not match its metadata.
+	tampered := tamperedSource{src, zero}
+	lock.Packages["web-search"].Digest = zero
+	_, err = linkModules(dir, lock, tampered, st)
+	assert.EqualError(t, err, "integrity check failed for web-search@1.0.0: downloaded archive is "+
+		published+", expected "+zero)
+	assert.False(t, st.Has(published), "a tampered archive is not unpacked")
+	assert.NoDirExists(t, filepath.Join(dir, ModulesDir))
+}
+
+func TestLinkModulesHostileNames(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "a", "b", "project")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	victim := filepath.Join(root, "victim")
+	require.NoError(t, os.MkdirAll(victim, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(victim, "keep.txt"), []byte("keep"), 0644))
+
+	st, err := store.Open(t.TempDir())
+	require.NoError(t, err)
+	src := fakeSource{"web-search": {"1.0.0": nil}}
+	lock := lockOf(t, src, map[string]string{"web-search": "1.0.0"})
+	_, err = linkModules(dir, lock, src, st)
+	require.NoError(t, err)
+
+	// The digest is already in the store, so nothing is downloaded.
+	lock.Packages["../../../victim"] = lock.Packages["web-search"]
+	_, err = linkModules(dir, lock, nil, st)
+	assert.ErrorContains(t, err, `refusing to install "../../../victim"`)
+	assert.FileExists(t, filepath.Join(victim, "keep.txt"))
+
+	// A hostile name in the state file is forgotten, not removed.
+	modules := filepath.Join(dir, ModulesDir)
+	require.NoError(t, saveModulesState(modules, map[string]string{"../../../victim": lock.Packages["web-search"].Digest}))
+	delete(lock.Packages, "../../../victim")
+	_, err = linkModules(dir, lock, nil, st)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(victim, "keep.txt"))
+}
+
+// tamperedSource publishes digest for every package but serves the
+// archives of fakeSource
+type tamperedSource struct {
+	fakeSource
+	digest string
+}
+
+func (s tamperedSource) Digest(name, version string) (string, error) {
+	return s.digest, nil
+}
+
+// offlineSource is a fakeSource whose archives are not in the offline cache
+type offlineSource struct {
+	fakeSource
+}
+
+func (s offlineSource) Fetch(name, version string) (io.ReadCloser, error) {
+	return nil, &registry.NotCachedError{Name: name, Version: version}
+}
+
+func TestLinkModulesOffline(t *testing.T) {
+	src := fakeSource{"web-search": {"1.0.0": nil}, "http-tool": {"1.0.0": nil}, "summarizer": {"2.0.0": nil}}
+	dir := t.TempDir()
+	st, err := store.Open(t.TempDir())
+	require.NoError(t, err)
+	lock := lockOf(t, src, map[string]string{"web-search": "1.0.0", "http-tool": "1.0.0", "summarizer": "2.0.0"})
+
+	// Packages already in the store need no cache.
+	_, err = linkModules(dir, lockOf(t, src, map[string]string{"summarizer": "2.0.0"}), src, st)
+	require.NoError(t, err)
+
+	_, err = linkModules(dir, lock, offlineSource{src}, st)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 packages are not in the offline cache: http-tool@1.0.0, web-search@1.0.0\n")
+	assert.Contains(t, err.Error(), "without --offline")
+}
+
+func TestLinkModulesOfflineByDigest(t *testing.T) {
+	local, err := registry.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	published, err := local.Publish(&registry.VersionInfo{Name: "web-search", Version: "1.0.0"},
+		bytes.NewReader(fakeArchive("web-search", "1.0.0")))
+	require.NoError(t, err)
+	cacheDir := t.TempDir()
+	online, err := registry.NewCache(local, cacheDir, registry.Online)
+	require.NoError(t, err)
+	rc, err := online.Fetch("web-search", "1.0.0")
+	require.NoError(t, err)
+	rc.Close()
+
+	// Only the archive is cached; the lock pins its digest.
+	require.NoError(t, os.RemoveAll(filepath.Join(cacheDir, "metadata")))
+	offline, err := registry.NewCache(local, cacheDir, registry.Offline)
+	require.NoError(t, err)
+	lock := &pkg.Lockfile{LockfileVersion: pkg.LockfileVersion, Packages: map[string]*pkg.LockedPackage{
+		"web-search": {Version: "1.0.0", Registry: local.Location(), Digest: published.Digest},
+	}}
+	st, err := store.Open(t.TempDir())
+	require.NoError(t, err)
+	dir := t.TempDir()
+	stats, err := linkModules(dir, lock, registry.NewSource(offline), st)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Linked)
+	assert.FileExists(t, filepath.Join(dir, ModulesDir, "web-search", "agentpkg.yaml"))
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"agenthub/pkg"
+)
+
+// SchemaOptions controls agenthub schema
+type SchemaOptions struct {
+	// Output is the file to write the schema to; empty prints it
+	Output string
+}
+
+// WriteSchema prints the JSON Schema of the manifest format or writes it
+// to opts.Output.
+func WriteSchema(opts SchemaOptions) error {
+	data, err := pkg.MarshalSchema()
+	if err != nil {
+		return err
+	}
+	if opts.Output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(opts.Output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	fmt.Printf("✅ Wrote manifest schema to %s\n", opts.Output)
+	return nil
+}
@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"agenthub/pkg"
+)
+
+func TestWriteSchema(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "agentpkg.schema.json")
+	require.NoError(t, WriteSchema(SchemaOptions{Output: output}))
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, pkg.SchemaDialect, schema["$schema"])
+	assert.Equal(t, "object", schema["type"])
+}
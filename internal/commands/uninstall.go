@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"agenthub/internal/registry"
+	"agenthub/pkg"
+)
+
+// UninstallOptions controls how dependencies are removed
+type UninstallOptions struct {
+	// Registry is the location of the registry the remaining dependencies
+	// are resolved from; empty selects the default registry
+	Registry string
+	// Token authenticates with HTTP registries
+	Token string
+	// Store is the directory of the package store; empty selects
+	// store.DefaultDir
+	Store string
+	// Global removes packages from the global prefix instead of the
+	// project in the current directory
+	Global bool
+	// GlobalDir is the global prefix; empty selects DefaultGlobalDir
+	GlobalDir string
+	// Network selects whether the registry or the offline cache of HTTP
+	// registries is used
+	Network registry.NetworkMode
+}
+
+func (o UninstallOptions) install() InstallOptions {
+	return InstallOptions{
+		Registry:  o.Registry,
+		Token:     o.Token,
+		Store:     o.Store,
+		Global:    o.Global,
+		GlobalDir: o.GlobalDir,
+		Network:   o.Network,
+	}
+}
+
+// UninstallPackages removes the named packages from the dependencies of the
+// project in the current directory, or of the global prefix with
+// opts.Global, and from agent_modules together with the packages only they
+// needed.
+func UninstallPackages(names []string, opts UninstallOptions) error {
+	dir, err := installDir(opts.install())
+	if err != nil {
+		return err
+	}
+	reg, err := openRegistry(dir, opts.Registry, registry.Options{Token: opts.Token, Network: opts.Network})
+	if err != nil {
+		return err
+	}
+	removed, err := uninstallPackages(dir, names, registry.NewSource(reg), opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Removed %d %s\n", len(removed), plural(len(removed), "package", "packages"))
+	return nil
+}
+
+// uninstallPackages removes names from the manifest in dir and installs
+// again, returning every package that is no longer locked
+func uninstallPackages(dir string, names []string, src packageSource, opts UninstallOptions) ([]string, error) {
+	manifestPath, err := pkg.FindAgentPkg(dir)
+	if err != nil {
+		return nil, err
+	}
+	agentPkg, err := pkg.LoadAgentPkg(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check every name before changing anything.
+	var edits []pkg.DependencyEdit
+	direct := make(map[string]bool)
+	for _, name := range names {
+		if err := pkg.ValidateName(name); err != nil {
+			return nil, err
+		}
+		_, isDep := agentPkg.Dependencies[name]
+		_, isDevDep := agentPkg.DevDependencies[name]
+		if !isDep && !isDevDep {
+			return nil, fmt.Errorf("%s is not a dependency of %s", name, agentPkg.Name)
+		}
+		direct[name] = true
+		for _, section := range pkg.DependencySections {
+			edits = append(edits, pkg.DependencyEdit{Section: section, Name: name})
+		}
+	}
+
+	lockPath := filepath.Join(dir, pkg.LockfileName)
+	previous, err := pkg.LoadLockfile(lockPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := pkg.EditDependencies(manifestPath, edits...); err != nil {
+		return nil, err
+	}
+	for _, name := range sortedNames(direct) {
+		fmt.Printf("Removed %s from %s\n", name, filepath.Base(manifestPath))
+	}
+	if err := installAll(dir, src, opts.install()); err != nil {
+		return nil, err
+	}
+
+	lock, err := pkg.LoadLockfile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range sortedNames(direct) {
+		if locked, ok := lock.Packages[name]; ok {
+			fmt.Printf("  %s@%s stays installed: other packages depend on it\n", name, locked.Version)
+		}
+	}
+	var removed []string
+	if previous != nil {
+		for _, name := range sortedNames(previous.Packages) {
+			if _, ok := lock.Packages[name]; ok {
+				continue
+			}
+			removed = append(removed, name)
+			if !direct[name] {
+				fmt.Printf("  %s@%s is no longer needed by any package\n", name, previous.Packages[name].Version)
+			}
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"agenthub/pkg"
+)
+
+func TestUninstallPackages(t *testing.T) {
+	src := fakeSource{
+		"web-search": {"2.0.0": {"http-tool": "^1.0.0", "html-parse": "^1.0.0"}},
+		"summarizer": {"1.0.0": {"http-tool": "^1.0.0"}},
+		"http-tool":  {"1.0.0": nil},
+		"html-parse": {"1.0.0": nil},
+		"eval-kit":   {"0.3.0": nil},
+	}
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\n# runtime\ndependencies:\n  summarizer: ^1.0.0\n  web-search: ^2.0.0\ndevDependencies:\n  eval-kit: ^0.3.0\n")
+	opts := UninstallOptions{Store: t.TempDir()}
+	require.NoError(t, installAll(dir, src, opts.install()))
+	modules := filepath.Join(dir, ModulesDir)
+	require.DirExists(t, filepath.Join(modules, "html-parse"))
+
+	removed, err := uninstallPackages(dir, []string{"web-search", "eval-kit"}, src, opts)
+	require.NoError(t, err)
+	// http-tool stays: summarizer needs it too.
+	assert.Equal(t, []string{"eval-kit", "html-parse", "web-search"}, removed)
+
+	data, err := os.ReadFile(filepath.Join(dir, "agentpkg.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: my-app\nversion: 1.0.0\n# runtime\ndependencies:\n  summarizer: ^1.0.0\n", string(data))
+	lock, err := pkg.LoadLockfile(filepath.Join(dir, pkg.LockfileName))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http-tool", "summarizer"}, sortedNames(lock.Packages))
+	assert.Empty(t, lock.DevDependencies)
+	for _, name := range removed {
+		assert.NoDirExists(t, filepath.Join(modules, name))
+	}
+	assert.DirExists(t, filepath.Join(modules, "http-tool"))
+
+	removed, err = uninstallPackages(dir, []string{"summarizer"}, src, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http-tool", "summarizer"}, removed)
+	assert.NoDirExists(t, modules)
+}
+
+func TestUninstallPackagesErrors(t *testing.T) {
+	src := fakeSource{"web-search": {"2.0.0": nil}}
+	dir := t.TempDir()
+	manifest := "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n"
+	writeManifest(t, dir, manifest)
+	opts := UninstallOptions{Store: t.TempDir()}
+
+	_, err := uninstallPackages(dir, []string{"web-search", "missing"}, src, opts)
+	assert.ErrorContains(t, err, "missing is not a dependency of my-app")
+	_, err = uninstallPackages(dir, []string{"Bad Name"}, src, opts)
+	assert.ErrorContains(t, err, "invalid package name")
+	_, err = uninstallPackages(t.TempDir(), []string{"web-search"}, src, opts)
+	assert.ErrorContains(t, err, "no agentpkg.yaml found")
+
+	// Nothing changes when any name is wrong.
+	data, err := os.ReadFile(filepath.Join(dir, "agentpkg.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, manifest, string(data))
+}
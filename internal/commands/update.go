@@ -0,0 +1,296 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"agenthub/internal/registry"
+	"agenthub/pkg"
+)
+
+// OutdatedOptions controls how agenthub outdated checks dependencies
+type OutdatedOptions struct {
+	// Registry is the location of the registry to check; empty selects the
+	// default registry
+	Registry string
+	// Token authenticates with HTTP registries
+	Token string
+	// Network selects whether the registry or the offline cache of HTTP
+	// registries is used
+	Network registry.NetworkMode
+}
+
+// OutdatedPackage describes the versions of a dependency
+type OutdatedPackage struct {
+	Name string
+	// Section is the manifest section the dependency is in
+	Section string
+	// Range is the version range in the manifest
+	Range string
+	// Current is the locked version, empty when the package is not locked
+	Current string
+	// Wanted is the newest version Range allows, empty when there is none
+	Wanted string
+	// Latest is the newest stable version, empty when there is none
+	Latest string
+}
+
+// Outdated reports whether a newer version than the current one exists.
+// An empty Wanted or Latest column means there is nothing to move to.
+func (p OutdatedPackage) Outdated() bool {
+	return (p.Wanted != "" && p.Current != p.Wanted) || (p.Latest != "" && p.Current != p.Latest)
+}
+
+// ShowOutdated prints the dependencies of the project in the current
+// directory that have newer versions.
+func ShowOutdated(opts OutdatedOptions) error {
+	reg, err := openRegistry(".", opts.Registry, registry.Options{Token: opts.Token, Network: opts.Network})
+	if err != nil {
+		return err
+	}
+	packages, err := outdatedPackages(".", registry.NewSource(reg))
+	if err != nil {
+		return err
+	}
+	writeOutdatedTable(os.Stdout, packages)
+	return nil
+}
+
+// outdatedPackages returns the versions of every direct dependency of the
+// project in dir, sorted by name
+func outdatedPackages(dir string, src packageSource) ([]OutdatedPackage, error) {
+	manifestPath, err := pkg.FindAgentPkg(dir)
+	if err != nil {
+		return nil, err
+	}
+	agentPkg, err := pkg.LoadAgentPkg(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := pkg.LoadLockfile(filepath.Join(dir, pkg.LockfileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	all := agentPkg.AllDependencies()
+	packages := make([]OutdatedPackage, 0, len(all))
+	for _, name := range sortedNames(all) {
+		p := OutdatedPackage{Name: name, Section: dependencySection(agentPkg, name), Range: all[name]}
+		if lock != nil && lock.Packages[name] != nil {
+			p.Current = lock.Packages[name].Version
+		}
+		versions, err := src.Versions(name)
+		if err != nil {
+			return nil, err
+		}
+		// A range nothing satisfies, or a package with only prereleases,
+		// leaves the column empty rather than failing the whole report.
+		p.Wanted, _ = pkg.ResolveVersion(p.Range, versions)
+		p.Latest, _ = pkg.ResolveVersion("latest", versions)
+		packages = append(packages, p)
+	}
+	return packages, nil
+}
+
+// writeOutdatedTable prints the outdated packages as a table
+func writeOutdatedTable(out io.Writer, packages []OutdatedPackage) {
+	var outdated []OutdatedPackage
+	for _, p := range packages {
+		if p.Outdated() {
+			outdated = append(outdated, p)
+		}
+	}
+	if len(outdated) == 0 {
+		fmt.Fprintln(out, "✅ All dependencies are up to date")
+		return
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Package\tCurrent\tWanted\tLatest\tSection")
+	for _, p := range outdated {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", p.Name, orDash(p.Current), orDash(p.Wanted), orDash(p.Latest), p.Section)
+	}
+	tw.Flush()
+	fmt.Fprintf(out, "\nRun `agenthub update` to move to the wanted versions, or `agenthub update --latest` to the latest.\n")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// dependencySection returns the manifest section that lists name
+func dependencySection(agentPkg *pkg.AgentPkg, name string) string {
+	if _, ok := agentPkg.Dependencies[name]; ok {
+		return pkg.DependencySections[0]
+	}
+	return pkg.DependencySections[1]
+}
+
+// UpdateOptions controls how agenthub update moves dependencies forward
+type UpdateOptions struct {
+	// Registry is the location of the registry to update from; empty
+	// selects the default registry
+	Registry string
+	// Token authenticates with HTTP registries
+	Token string
+	// Store is the directory of the package store; empty selects
+	// store.DefaultDir
+	Store string
+	// Latest also changes manifest ranges that do not allow the latest
+	// version so they do
+	Latest bool
+	// Network selects whether the registry or the offline cache of HTTP
+	// registries is used
+	Network registry.NetworkMode
+}
+
+// UpdatePackages moves the named dependencies of the project in the
+// current directory, or all of them when names is empty, to the newest
+// versions their ranges allow, and installs them.
+func UpdatePackages(names []string, opts UpdateOptions) error {
+	reg, err := openRegistry(".", opts.Registry, registry.Options{Token: opts.Token, Network: opts.Network})
+	if err != nil {
+		return err
+	}
+	changed, err := updatePackages(".", names, registry.NewSource(reg), opts)
+	if err != nil {
+		return err
+	}
+	if changed == 0 {
+		fmt.Println("✅ All packages are up to date")
+		return nil
+	}
+	fmt.Printf("✅ Updated %d %s\n", changed, plural(changed, "package", "packages"))
+	return nil
+}
+
+// updatePackages updates the project in dir and returns how many locked
+// packages changed version or were added
+func updatePackages(dir string, names []string, src packageSource, opts UpdateOptions) (int, error) {
+	manifestPath, err := pkg.FindAgentPkg(dir)
+	if err != nil {
+		return 0, err
+	}
+	agentPkg, err := pkg.LoadAgentPkg(manifestPath)
+	if err != nil {
+		return 0, err
+	}
+	all := agentPkg.AllDependencies()
+	for _, name := range names {
+		if _, ok := all[name]; !ok {
+			return 0, fmt.Errorf("%s is not a dependency of %s", name, agentPkg.Name)
+		}
+	}
+	targets := names
+	if len(targets) == 0 {
+		targets = sortedNames(all)
+	}
+
+	lockPath := filepath.Join(dir, pkg.LockfileName)
+	previous, err := pkg.LoadLockfile(lockPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+
+	var edits []pkg.DependencyEdit
+	if opts.Latest {
+		if edits, agentPkg, err = bumpRanges(agentPkg, targets, src); err != nil {
+			return 0, err
+		}
+	}
+
+	// Every other package keeps its locked version where it can.
+	var preferred map[string]string
+	if previous != nil && len(names) > 0 {
+		preferred = previous.Preferred()
+		for _, name := range names {
+			delete(preferred, name)
+		}
+	}
+	lock, err := resolveLockfile(agentPkg, src, preferred)
+	if err != nil {
+		return 0, err
+	}
+
+	// The manifest is only written once the bumped ranges resolve, so a
+	// conflict leaves it and the lock as they were.
+	if len(edits) > 0 {
+		if err := pkg.EditDependencies(manifestPath, edits...); err != nil {
+			return 0, err
+		}
+		for _, e := range edits {
+			fmt.Printf("  %s %s → %s in %s\n", e.Name, all[e.Name], e.Constraint, filepath.Base(manifestPath))
+		}
+	}
+	if err := lock.Save(lockPath); err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, name := range sortedNames(lock.Packages) {
+		version := lock.Packages[name].Version
+		var old *pkg.LockedPackage
+		if previous != nil {
+			old = previous.Packages[name]
+		}
+		switch {
+		case old == nil:
+			fmt.Printf("  %s %s (new)\n", name, version)
+			changed++
+		case old.Version != version:
+			fmt.Printf("  %s %s → %s\n", name, old.Version, version)
+			changed++
+		}
+	}
+	fmt.Printf("Wrote %s\n", pkg.LockfileName)
+	return changed, installLocked(dir, lock, src, opts.install())
+}
+
+// bumpRanges returns the edits that change the range of each of names that
+// does not allow the latest version into one that does, and a copy of the
+// manifest with them applied. The manifest file is left alone.
+func bumpRanges(agentPkg *pkg.AgentPkg, names []string, src packageSource) ([]pkg.DependencyEdit, *pkg.AgentPkg, error) {
+	all := agentPkg.AllDependencies()
+	var edits []pkg.DependencyEdit
+	for _, name := range names {
+		versions, err := src.Versions(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		latest, err := pkg.ResolveVersion("latest", versions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if wanted, _ := pkg.ResolveVersion(all[name], versions); wanted == latest {
+			continue
+		}
+		edits = append(edits, pkg.DependencyEdit{Section: dependencySection(agentPkg, name), Name: name, Constraint: bumpRange(all[name], latest)})
+	}
+	return edits, agentPkg.WithDependencyEdits(edits...), nil
+}
+
+// bumpRange returns a range of the same sort as constraint that allows
+// version: a tilde range stays one, an exact version stays exact, and
+// anything else becomes a caret range.
+func bumpRange(constraint, version string) string {
+	constraint = strings.TrimSpace(constraint)
+	if strings.HasPrefix(constraint, "~") {
+		return "~" + version
+	}
+	if _, err := pkg.ParseVersion(constraint); err == nil {
+		return version
+	}
+	return "^" + version
+}
+
+func (o UpdateOptions) install() InstallOptions {
+	return InstallOptions{Registry: o.Registry, Token: o.Token, Store: o.Store, Network: o.Network}
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"agenthub/pkg"
+)
+
+func TestOutdatedPackages(t *testing.T) {
+	src := fakeSource{
+		"web-search": {"2.0.0": nil, "2.1.0": nil, "3.0.0": nil},
+		"http-tool":  {"1.0.0": nil},
+		"eval-kit":   {"0.3.0": nil, "0.4.0-beta.1": nil},
+		"fresh":      {"1.0.0": nil, "1.1.0": nil},
+	}
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: 2.0.0\n  http-tool: ^1.0.0\ndevDependencies:\n  eval-kit: ^0.3.0\n")
+	require.NoError(t, installAll(dir, src, InstallOptions{Store: t.TempDir()}))
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n  http-tool: ^1.0.0\n  fresh: ^1.0.0\ndevDependencies:\n  eval-kit: ^0.3.0\n")
+
+	packages, err := outdatedPackages(dir, src)
+	require.NoError(t, err)
+	assert.Equal(t, []OutdatedPackage{
+		{Name: "eval-kit", Section: "devDependencies", Range: "^0.3.0", Current: "0.3.0", Wanted: "0.3.0", Latest: "0.3.0"},
+		{Name: "fresh", Section: "dependencies", Range: "^1.0.0", Wanted: "1.1.0", Latest: "1.1.0"},
+		{Name: "http-tool", Section: "dependencies", Range: "^1.0.0", Current: "1.0.0", Wanted: "1.0.0", Latest: "1.0.0"},
+		{Name: "web-search", Section: "dependencies", Range: "^2.0.0", Current: "2.0.0", Wanted: "2.1.0", Latest: "3.0.0"},
+	}, packages)
+
+	var out bytes.Buffer
+	writeOutdatedTable(&out, packages)
+	assert.Equal(t, ""+
+		"Package     Current  Wanted  Latest  Section\n"+
+		"fresh       -        1.1.0   1.1.0   dependencies\n"+
+		"web-search  2.0.0    2.1.0   3.0.0   dependencies\n"+
+		"\nRun `agenthub update` to move to the wanted versions, or `agenthub update --latest` to the latest.\n",
+		out.String())
+
+	out.Reset()
+	writeOutdatedTable(&out, packages[2:3])
+	assert.Equal(t, "✅ All dependencies are up to date\n", out.String())
+
+	_, err = outdatedPackages(t.TempDir(), src)
+	assert.ErrorContains(t, err, "no agentpkg.yaml found")
+}
+
+func TestOutdatedPackageEmptyColumns(t *testing.T) {
+	// Only prereleases are published, so there is no latest stable version.
+	prerelease := OutdatedPackage{Name: "nightly", Range: "^0.4.0-beta.1", Current: "0.4.0-beta.1", Wanted: "0.4.0-beta.1"}
+	assert.False(t, prerelease.Outdated())
+	// Nothing satisfies the range.
+	unsatisfiable := OutdatedPackage{Name: "web-search", Range: "^9.0.0", Current: "2.0.0", Latest: "2.0.0"}
+	assert.False(t, unsatisfiable.Outdated())
+	unsatisfiable.Latest = "3.0.0"
+	assert.True(t, unsatisfiable.Outdated())
+	assert.True(t, OutdatedPackage{Name: "fresh", Wanted: "1.1.0"}.Outdated(), "a package that is not locked is outdated")
+}
+
+func TestUpdatePackages(t *testing.T) {
+	src := fakeSource{
+		"web-search": {"2.0.0": {"http-tool": "^1.0.0"}},
+		"http-tool":  {"1.0.0": nil},
+		"summarizer": {"1.0.0": nil},
+	}
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n  summarizer: ~1.0.0 # keep\n")
+	opts := UpdateOptions{Store: t.TempDir()}
+	require.NoError(t, installAll(dir, src, opts.install()))
+	versions := func() map[string]string {
+		lock, err := pkg.LoadLockfile(filepath.Join(dir, pkg.LockfileName))
+		require.NoError(t, err)
+		return lock.Preferred()
+	}
+
+	src["web-search"]["2.1.0"] = map[string]string{"http-tool": "^1.0.0"}
+	src["web-search"]["3.0.0"] = map[string]string{"http-tool": "^1.1.0"}
+	src["http-tool"]["1.1.0"] = nil
+	src["summarizer"]["1.0.1"] = nil
+	src["summarizer"]["1.2.0"] = nil
+
+	// Updating one package leaves the others locked.
+	changed, err := updatePackages(dir, []string{"web-search"}, src, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	assert.Equal(t, map[string]string{"web-search": "2.1.0", "http-tool": "1.0.0", "summarizer": "1.0.0"}, versions())
+
+	changed, err = updatePackages(dir, nil, src, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	assert.Equal(t, map[string]string{"web-search": "2.1.0", "http-tool": "1.1.0", "summarizer": "1.0.1"}, versions())
+
+	changed, err = updatePackages(dir, nil, src, UpdateOptions{Store: opts.Store, Latest: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	assert.Equal(t, map[string]string{"web-search": "3.0.0", "http-tool": "1.1.0", "summarizer": "1.2.0"}, versions())
+	data, err := os.ReadFile(filepath.Join(dir, "agentpkg.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^3.0.0\n  summarizer: ~1.2.0 # keep\n", string(data))
+	assert.FileExists(t, filepath.Join(dir, ModulesDir, "web-search", "agentpkg.yaml"))
+
+	_, err = updatePackages(dir, []string{"missing"}, src, opts)
+	assert.ErrorContains(t, err, "missing is not a dependency of my-app")
+}
+
+func TestUpdatePackagesAddsTransitiveDependency(t *testing.T) {
+	src := fakeSource{
+		"web-search": {"2.0.0": nil},
+		"http-tool":  {"1.0.0": nil},
+	}
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-app\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\n")
+	opts := UpdateOptions{Store: t.TempDir()}
+	require.NoError(t, installAll(dir, src, opts.install()))
+
+	// web-search 2.0.1 needs http-tool, which is new to the lock.
+	delete(src["web-search"], "2.0.0")
+	src["web-search"]["2.0.1"] = map[string]string{"http-tool": "^1.0.0"}
+	changed, err := updatePackages(dir, nil, src, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+
+	src["web-search"]["2.0.1"] = nil
+	changed, err = updatePackages(dir, nil, src, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, changed, "a package leaving the lock is not an update")
+}
+
+func TestUpdatePackagesLatestConflict(t *testing.T) {
+	src := fakeSource{
+		"a": {"1.0.0": nil, "2.0.0": nil},
+		"b": {"1.0.0": {"a": "^1.0.0"}},
+	}
+	dir := t.TempDir()
+	manifest := "name: my-app\nversion: 1.0.0\ndependencies:\n  a: ^1.0.0\n  b: ^1.0.0\n"
+	writeManifest(t, dir, manifest)
+	opts := UpdateOptions{Store: t.TempDir()}
+	require.NoError(t, installAll(dir, src, opts.install()))
+	lockPath := filepath.Join(dir, pkg.LockfileName)
+	lock, err := os.ReadFile(lockPath)
+	require.NoError(t, err)
+
+	// Bumping a to ^2.0.0 conflicts with b, so nothing is written.
+	_, err = updatePackages(dir, []string{"a"}, src, UpdateOptions{Store: opts.Store, Latest: true})
+	require.Error(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, "agentpkg.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, manifest, string(data))
+	data, err = os.ReadFile(lockPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(lock), string(data))
+}
+
+func TestBumpRange(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.0.0", "^2.1.0"},
+		{"~1.0.0", "~2.1.0"},
+		{"1.0.0", "2.1.0"},
+		{">=1.0.0 <2.0.0", "^2.1.0"},
+		{"1.x", "^2.1.0"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, bumpRange(tt.constraint, "2.1.0"), tt.constraint)
+	}
+}
@@ -0,0 +1,314 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"agenthub/internal/archive"
+	"agenthub/pkg"
+)
+
+// Exit codes of agenthub validate
+const (
+	ExitValidationErrors   = 1
+	ExitValidationWarnings = 2
+)
+
+// ValidationFormats lists the output formats of agenthub validate.
+var ValidationFormats = []string{"text", "json", "sarif"}
+
+// ExitError makes the process exit with Code. Err, when set, is printed
+// first; without it the command has already reported what went wrong.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateOptions controls agenthub validate
+type ValidateOptions struct {
+	Format string
+}
+
+// PackageReport is the result of validating one package.
+type PackageReport struct {
+	Path     string        `json:"path"`
+	Manifest string        `json:"manifest,omitempty"`
+	Name     string        `json:"name,omitempty"`
+	Version  string        `json:"version,omitempty"`
+	Valid    bool          `json:"valid"`
+	Errors   int           `json:"errors"`
+	Warnings int           `json:"warnings"`
+	Problems []pkg.Problem `json:"problems"`
+}
+
+// checkPackage loads the package in dir and checks its manifest and the
+// files the manifest refers to. The error is only set when the manifest
+// cannot be found or decoded.
+func checkPackage(dir string) (*pkg.AgentPkg, []pkg.Problem, error) {
+	manifestPath, err := pkg.FindAgentPkg(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := archive.Files(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pkg.ValidatePackage(manifestPath, files)
+}
+
+// ValidatePackages validates the packages in dirs, the current directory
+// when none are given, and prints a report in opts.Format. It fails with
+// an *ExitError when a package has errors, or only warnings.
+func ValidatePackages(dirs []string, opts ValidateOptions) error {
+	return validatePackages(os.Stdout, dirs, opts)
+}
+
+func validatePackages(out io.Writer, dirs []string, opts ValidateOptions) error {
+	format := opts.Format
+	if format == "" {
+		format = "text"
+	}
+	var write func(io.Writer, []PackageReport) error
+	switch format {
+	case "text":
+		write = writeTextReport
+	case "json":
+		write = writeJSONReport
+	case "sarif":
+		write = writeSARIFReport
+	default:
+		return fmt.Errorf("unknown format %q: must be one of %s", format, strings.Join(ValidationFormats, ", "))
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	reports := make([]PackageReport, 0, len(dirs))
+	errs, warnings := 0, 0
+	for _, dir := range dirs {
+		report := validateDir(dir)
+		errs += report.Errors
+		warnings += report.Warnings
+		reports = append(reports, report)
+	}
+	if err := write(out, reports); err != nil {
+		return err
+	}
+
+	switch {
+	case errs > 0:
+		return &ExitError{Code: ExitValidationErrors}
+	case warnings > 0:
+		return &ExitError{Code: ExitValidationWarnings}
+	}
+	return nil
+}
+
+// validateDir validates the package in dir. A manifest that is missing or
+// cannot be decoded is reported as an error of the package.
+func validateDir(dir string) PackageReport {
+	report := PackageReport{Path: dir, Problems: []pkg.Problem{}}
+	agentPkg, problems, err := checkPackage(dir)
+	if manifestPath, findErr := pkg.FindAgentPkg(dir); findErr == nil {
+		report.Manifest = manifestPath
+	}
+	if err != nil {
+		problems = []pkg.Problem{{
+			Severity: pkg.SeverityError,
+			Message:  err.Error(),
+			File:     report.Manifest,
+		}}
+	} else {
+		report.Name, report.Version = agentPkg.Name, agentPkg.Version
+	}
+	for _, p := range problems {
+		if p.Severity == pkg.SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	report.Problems = append(report.Problems, problems...)
+	report.Valid = report.Errors == 0
+	return report
+}
+
+func writeTextReport(w io.Writer, reports []PackageReport) error {
+	errs, warnings := 0, 0
+	for _, r := range reports {
+		label := r.Path
+		if r.Name != "" {
+			label = fmt.Sprintf("%s@%s (%s)", r.Name, r.Version, r.Path)
+		}
+		for _, p := range r.Problems {
+			icon := "❌"
+			if p.Severity == pkg.SeverityWarning {
+				icon = "⚠️ "
+			}
+			fmt.Fprintf(w, "%s %s\n", icon, p)
+		}
+		switch {
+		case r.Errors > 0:
+			fmt.Fprintf(w, "❌ %s: %d %s, %d %s\n", label,
+				r.Errors, plural(r.Errors, "error", "errors"), r.Warnings, plural(r.Warnings, "warning", "warnings"))
+		case r.Warnings > 0:
+			fmt.Fprintf(w, "⚠️  %s is valid with %d %s\n", label, r.Warnings, plural(r.Warnings, "warning", "warnings"))
+		default:
+			fmt.Fprintf(w, "✅ %s is valid\n", label)
+		}
+		errs += r.Errors
+		warnings += r.Warnings
+	}
+	if len(reports) > 1 {
+		fmt.Fprintf(w, "\nValidated %d packages: %d %s, %d %s\n", len(reports),
+			errs, plural(errs, "error", "errors"), warnings, plural(warnings, "warning", "warnings"))
+	}
+	return nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func writeJSONReport(w io.Writer, reports []PackageReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Packages []PackageReport `json:"packages"`
+	}{reports})
+}
+
+// The subset of SARIF 2.1.0 that code scanning services read.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifRuleID names the rule of a problem after the top-level manifest
+// field it concerns.
+func sarifRuleID(p pkg.Problem) string {
+	field := p.Field
+	if i := strings.IndexAny(field, ".["); i >= 0 {
+		field = field[:i]
+	}
+	if field == "" {
+		return "agenthub/manifest"
+	}
+	return "agenthub/" + field
+}
+
+func writeSARIFReport(w io.Writer, reports []PackageReport) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:  "agenthub",
+			Rules: []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	seen := make(map[string]bool)
+	for _, r := range reports {
+		for _, p := range r.Problems {
+			id := sarifRuleID(p)
+			if !seen[id] {
+				seen[id] = true
+				field := strings.TrimPrefix(id, "agenthub/")
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					ID:               id,
+					ShortDescription: sarifMessage{Text: fmt.Sprintf("Problems with the %s of an AgentHub package", field)},
+				})
+			}
+			result := sarifResult{
+				RuleID:  id,
+				Level:   string(p.Severity),
+				Message: sarifMessage{Text: p.Message},
+			}
+			if p.Field != "" {
+				result.Message.Text = p.Field + ": " + p.Message
+			}
+			if p.File != "" {
+				loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(p.File)}}
+				if p.Line > 0 {
+					loc.Region = &sarifRegion{StartLine: p.Line, StartColumn: p.Column}
+				}
+				result.Locations = []sarifLocation{{PhysicalLocation: loc}}
+			}
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
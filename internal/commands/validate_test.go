@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePackagesExitCodes(t *testing.T) {
+	valid := t.TempDir()
+	writeManifest(t, valid, "name: valid\nversion: 1.0.0\ndescription: A package\nlicense: MIT\n")
+	warned := t.TempDir()
+	writeManifest(t, warned, "name: warned\nversion: 1.0.0\n")
+	invalid := t.TempDir()
+	writeManifest(t, invalid, "name: Invalid\nversion: 1.0.0\n")
+	empty := t.TempDir()
+
+	tests := []struct {
+		name string
+		dirs []string
+		code int
+	}{
+		{"valid", []string{valid}, 0},
+		{"warnings only", []string{valid, warned}, ExitValidationWarnings},
+		{"errors", []string{warned, invalid}, ExitValidationErrors},
+		{"missing manifest", []string{empty}, ExitValidationErrors},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := validatePackages(&out, tt.dirs, ValidateOptions{})
+			if tt.code == 0 {
+				require.NoError(t, err)
+				assert.Contains(t, out.String(), "✅ valid@1.0.0")
+				return
+			}
+			var exitErr *ExitError
+			require.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, tt.code, exitErr.Code)
+			assert.Nil(t, exitErr.Err)
+		})
+	}
+}
+
+func TestValidatePackagesText(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: agent\nversion: 1.0.0\nlicense: MIT\nkind: agent\nagent:\n  model: {name: m}\n  prompt: prompts/system.md\n")
+
+	var out bytes.Buffer
+	err := validatePackages(&out, []string{dir}, ValidateOptions{Format: "text"})
+	require.Error(t, err)
+	manifest := filepath.Join(dir, "agentpkg.yaml")
+	assert.Contains(t, out.String(), "⚠️  "+manifest+": warning: description: ")
+	assert.Contains(t, out.String(), "❌ "+manifest+":7:11: error: agent.prompt: prompts/system.md does not exist")
+	assert.Contains(t, out.String(), "❌ agent@1.0.0 ("+dir+"): 1 error, 1 warning")
+	assert.NoDirExists(t, filepath.Join(dir, "dist"), "validating does not build")
+}
+
+func TestValidatePackagesJSON(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: agent\nversion: one\n")
+
+	var out bytes.Buffer
+	require.Error(t, validatePackages(&out, []string{dir}, ValidateOptions{Format: "json"}))
+
+	var report struct {
+		Packages []PackageReport `json:"packages"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.Len(t, report.Packages, 1)
+	p := report.Packages[0]
+	assert.Equal(t, dir, p.Path)
+	assert.Equal(t, "agent", p.Name)
+	assert.False(t, p.Valid)
+	assert.Equal(t, 1, p.Errors)
+	assert.Equal(t, 2, p.Warnings)
+	for _, problem := range p.Problems {
+		if problem.Field == "version" {
+			assert.Equal(t, 2, problem.Line)
+			assert.Equal(t, "error", string(problem.Severity))
+		}
+	}
+}
+
+func TestValidatePackagesSARIF(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: agent\nversion: 1.0.0\ndescription: An agent\nlicense: mit\n")
+
+	var out bytes.Buffer
+	err := validatePackages(&out, []string{dir}, ValidateOptions{Format: "sarif"})
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, ExitValidationWarnings, exitErr.Code)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(out.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Results, 1)
+	result := log.Runs[0].Results[0]
+	assert.Equal(t, "agenthub/license", result.RuleID)
+	assert.Equal(t, "warning", result.Level)
+	assert.Equal(t, "license: license mit should be written MIT", result.Message.Text)
+	loc := result.Locations[0].PhysicalLocation
+	assert.Equal(t, filepath.ToSlash(filepath.Join(dir, "agentpkg.yaml")), loc.ArtifactLocation.URI)
+	assert.Equal(t, &sarifRegion{StartLine: 4, StartColumn: 10}, loc.Region)
+	assert.Equal(t, "agenthub/license", log.Runs[0].Tool.Driver.Rules[0].ID)
+}
+
+func TestValidatePackagesUnknownFormat(t *testing.T) {
+	err := validatePackages(os.Stdout, nil, ValidateOptions{Format: "xml"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown format "xml"`)
+}
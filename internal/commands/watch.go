@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"agenthub/internal/archive"
+)
+
+// watchDebounce is how long watch mode waits after the last change before
+// rebuilding, so that an editor saving several files triggers one build
+const watchDebounce = 200 * time.Millisecond
+
+// fileState is what watch mode remembers about a packaged file to decide
+// whether it changed since the last build. The digest is only recomputed
+// when the size or modification time moves.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	digest  [sha256.Size]byte
+}
+
+// watchPackage builds the package in dir, then rebuilds it until ctx is
+// done whenever the content of a file that goes into its archive changes.
+// Changes to other files, and saves that leave the content as it was, do
+// not rebuild. Build failures are reported and watching continues.
+func watchPackage(ctx context.Context, dir string, opts BuildOptions, debounce time.Duration) error {
+	opts.Watch = false
+	outputDir, err := filepath.Abs(buildOutputDir(dir, opts))
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watching: %w", err)
+	}
+	defer watcher.Close()
+	if err := watchTree(watcher, dir, dir, outputDir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	if err := buildPackage(dir, opts); err != nil {
+		fmt.Printf("❌ Build failed: %v\n", err)
+	}
+	snapshot, _ := packageSnapshot(dir, outputDir, nil)
+	fmt.Println("👀 Watching for changes (press Ctrl+C to stop)...")
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if within(outputDir, event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					watchTree(watcher, dir, event.Name, outputDir)
+				}
+			}
+			timer.Reset(debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Printf("⚠️  Watch error: %v\n", err)
+
+		case <-timer.C:
+			current, err := packageSnapshot(dir, outputDir, snapshot)
+			if err != nil {
+				fmt.Printf("❌ Build failed: %v\n", err)
+				snapshot = nil
+				continue
+			}
+			changed := changedFiles(snapshot, current)
+			snapshot = current
+			if len(changed) == 0 {
+				continue
+			}
+			fmt.Printf("🔄 Changed: %s\n", summarizePaths(changed, 3))
+			rebuild(dir, outputDir, opts)
+		}
+	}
+}
+
+// rebuild builds the package for watch mode, reporting the outcome on one
+// line
+func rebuild(dir, outputDir string, opts BuildOptions) {
+	start := time.Now()
+	agentPkg, err := loadPackage(dir)
+	if err != nil {
+		fmt.Printf("❌ Build failed: %v\n", err)
+		return
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		fmt.Printf("❌ Build failed: failed to create output directory: %v\n", err)
+		return
+	}
+	meta, err := archive.Build(dir, outputDir, agentPkg)
+	if err != nil {
+		fmt.Printf("❌ Build failed: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Rebuilt %s in %s (%d files, %d bytes, %s)\n",
+		filepath.Join(opts.OutputDir, meta.Archive), time.Since(start).Round(time.Millisecond),
+		len(meta.Files), meta.Size, meta.Digest)
+}
+
+// watchTree adds root, a directory of the package in dir, and every
+// directory below it to watcher. It skips the build output, installed
+// dependencies and the directories the packer ignores, so exactly the
+// directories that can hold packaged files are watched.
+func watchTree(watcher *fsnotify.Watcher, dir, root, outputDir string) error {
+	ig, err := archive.LoadIgnore(dir)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if within(outputDir, path) {
+			return filepath.SkipDir
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != "." {
+			rel = filepath.ToSlash(rel)
+			if rel == ModulesDir || ig.Match(rel, true) {
+				return filepath.SkipDir
+			}
+		}
+		return watcher.Add(path)
+	})
+}
+
+// within reports whether path is dir or inside it
+func within(dir, path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// packageSnapshot records the state of every file that goes into the
+// archive of the package in dir, reusing the digests in previous for files
+// whose size and modification time are unchanged
+func packageSnapshot(dir, outputDir string, previous map[string]fileState) (map[string]fileState, error) {
+	files, err := archive.BuildFiles(dir, outputDir)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(map[string]fileState, len(files))
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		state := fileState{size: info.Size(), modTime: info.ModTime()}
+		if prev, ok := previous[name]; ok && prev.size == state.size && prev.modTime.Equal(state.modTime) {
+			state.digest = prev.digest
+		} else if state.digest, err = fileDigest(path); err != nil {
+			continue
+		}
+		snapshot[name] = state
+	}
+	return snapshot, nil
+}
+
+func fileDigest(path string) ([sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+	f, err := os.Open(path)
+	if err != nil {
+		return digest, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return digest, err
+	}
+	copy(digest[:], hash.Sum(nil))
+	return digest, nil
+}
+
+// changedFiles returns the sorted paths that were added, removed or
+// modified between two snapshots. A nil previous snapshot, from a failed
+// listing, counts every current file as changed.
+func changedFiles(previous, current map[string]fileState) []string {
+	var changed []string
+	for name, state := range current {
+		if prev, ok := previous[name]; !ok || prev.size != state.size || prev.digest != state.digest {
+			changed = append(changed, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// summarizePaths joins up to max paths, noting how many were left out
+func summarizePaths(paths []string, max int) string {
+	if len(paths) <= max {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s (+%d more)", strings.Join(paths[:max], ", "), len(paths)-max)
+}
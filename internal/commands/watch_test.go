@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"agenthub/internal/archive"
+)
+
+func readBuildMetadata(path string) *archive.Metadata {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var meta archive.Metadata
+	if json.Unmarshal(data, &meta) != nil {
+		return nil
+	}
+	return &meta
+}
+
+func TestWatchPackageRebuildsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-agent\nversion: 1.2.0\n")
+	metaPath := filepath.Join(dir, "dist", "my-agent-1.2.0.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- watchPackage(ctx, dir, BuildOptions{OutputDir: "dist"}, 20*time.Millisecond)
+	}()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	require.Eventually(t, func() bool {
+		meta := readBuildMetadata(metaPath)
+		return meta != nil && len(meta.Files) == 1
+	}, 5*time.Second, 10*time.Millisecond, "initial build")
+
+	// A file in a new content directory is picked up.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "prompts"), 0755))
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prompts", "system.md"), []byte("Hello\n"), 0644))
+
+	require.Eventually(t, func() bool {
+		meta := readBuildMetadata(metaPath)
+		return meta != nil && len(meta.Files) == 2
+	}, 5*time.Second, 10*time.Millisecond, "rebuild after change")
+}
+
+func TestWatchTreeFollowsIgnoreRules(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-agent\nversion: 1.2.0\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, archive.IgnoreFileName), []byte("tmp/\n"), 0644))
+	for _, sub := range []string{".config/prompts", ".git/objects", "agent_modules/web-search", "tmp", "dist", "prompts"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.FromSlash(sub)), 0755))
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	defer watcher.Close()
+	require.NoError(t, watchTree(watcher, dir, dir, filepath.Join(dir, "dist")))
+
+	var watched []string
+	for _, path := range watcher.WatchList() {
+		rel, err := filepath.Rel(dir, path)
+		require.NoError(t, err)
+		watched = append(watched, filepath.ToSlash(rel))
+	}
+	// Hidden directories are packed unless ignored, so they are watched.
+	assert.ElementsMatch(t, []string{".", ".config", ".config/prompts", "prompts"}, watched)
+}
+
+func TestChangedFiles(t *testing.T) {
+	now := time.Now()
+	previous := map[string]fileState{
+		"agentpkg.yaml":     {size: 10, modTime: now, digest: [32]byte{1}},
+		"prompts/a.md":      {size: 5, modTime: now, digest: [32]byte{2}},
+		"prompts/remove.md": {size: 1, modTime: now, digest: [32]byte{3}},
+	}
+	current := map[string]fileState{
+		"agentpkg.yaml": {size: 10, modTime: now.Add(time.Second), digest: [32]byte{1}},
+		"prompts/a.md":  {size: 5, modTime: now, digest: [32]byte{4}},
+		"prompts/b.md":  {size: 2, modTime: now, digest: [32]byte{5}},
+	}
+
+	// Saving agentpkg.yaml without changing it is not a change.
+	assert.Equal(t, []string{"prompts/a.md", "prompts/b.md", "prompts/remove.md"}, changedFiles(previous, current))
+	assert.Empty(t, changedFiles(current, current))
+	assert.Len(t, changedFiles(nil, current), 3)
+}
+
+func TestPackageSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "name: my-agent\nversion: 1.2.0\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, archive.IgnoreFileName), []byte("notes.txt\n"), 0644))
+	outputDir := filepath.Join(dir, "dist")
+	snapshot, err := packageSnapshot(dir, outputDir, nil)
+	require.NoError(t, err)
+
+	// Files left out of the archive and touched files do not count.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("todo\n"), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "agentpkg.yaml"), later, later))
+	current, err := packageSnapshot(dir, outputDir, snapshot)
+	require.NoError(t, err)
+	assert.Empty(t, changedFiles(snapshot, current))
+
+	writeManifest(t, dir, "name: my-agent\nversion: 1.2.1\n")
+	current, err = packageSnapshot(dir, outputDir, current)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agentpkg.yaml"}, changedFiles(snapshot, current))
+}
+
+func TestSummarizePaths(t *testing.T) {
+	assert.Equal(t, "a, b", summarizePaths([]string{"a", "b"}, 3))
+	assert.Equal(t, "a, b, c (+2 more)", summarizePaths([]string{"a", "b", "c", "d", "e"}, 3))
+}
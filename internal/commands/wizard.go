@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"agenthub/internal/registry"
+	"agenthub/internal/templates"
+	"agenthub/pkg"
+)
+
+// emptyKind is the wizard's choice for a project without a template
+const emptyKind = "empty"
+
+// isTerminal reports whether f is an interactive terminal rather than a
+// pipe, regular file or device such as /dev/null
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// wizard asks questions on out and reads the answers from in, one per line
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prints question with its default and returns the answer, or def
+// when the answer is empty. Answers rejected by validate are reported and
+// the question asked again.
+func (w *wizard) ask(question, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(w.out, "%s (%s): ", question, def)
+		} else {
+			fmt.Fprintf(w.out, "%s: ", question)
+		}
+		line, err := w.in.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			if errors.Is(err, io.EOF) {
+				return "", fmt.Errorf("init wizard: input ended before %q was answered", question)
+			}
+			return "", fmt.Errorf("init wizard: %w", err)
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if validate == nil {
+			return answer, nil
+		}
+		if err := validate(answer); err != nil {
+			fmt.Fprintf(w.out, "  ❌ %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// choose asks for one of choices, accepted by name or by number
+func (w *wizard) choose(question string, choices []string, def string) (string, error) {
+	for i, c := range choices {
+		fmt.Fprintf(w.out, "  %d) %s\n", i+1, c)
+	}
+	var choice string
+	_, err := w.ask(question, def, func(answer string) error {
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+			choice = choices[n-1]
+			return nil
+		}
+		if slices.Contains(choices, answer) {
+			choice = answer
+			return nil
+		}
+		return fmt.Errorf("choose one of %s", strings.Join(choices, ", "))
+	})
+	return choice, err
+}
+
+// runInitWizard asks for the details of a new project, offering the values
+// already in opts as defaults, and returns the options to initialize it
+// with
+func runInitWizard(in io.Reader, out io.Writer, projectName string, opts InitOptions) (InitOptions, error) {
+	w := &wizard{in: bufio.NewReader(in), out: out}
+	fmt.Fprintln(out, "This wizard creates a new AgentHub project. Press Enter to accept a default.")
+
+	if _, err := templates.Lookup(opts.Template); opts.Template == "" || err == nil {
+		def := opts.Template
+		if def == "" {
+			def = "agent"
+		}
+		kinds := append(templates.Names(), emptyKind)
+		kind, err := w.choose("Package kind", kinds, def)
+		if err != nil {
+			return opts, err
+		}
+		opts.Template = kind
+		if kind == emptyKind {
+			opts.Template = ""
+		}
+	}
+
+	name := opts.Name
+	if name == "" {
+		if abs, err := filepath.Abs(projectName); err == nil {
+			name = filepath.Base(abs)
+		}
+	}
+	var err error
+	if opts.Name, err = w.ask("Package name", name, pkg.ValidateName); err != nil {
+		return opts, err
+	}
+
+	version := opts.Version
+	if version == "" {
+		version = "0.1.0"
+	}
+	if opts.Version, err = w.ask("Version", version, func(answer string) error {
+		_, err := pkg.ParseVersion(answer)
+		return err
+	}); err != nil {
+		return opts, err
+	}
+
+	if opts.Description, err = w.ask("Description", opts.Description, nil); err != nil {
+		return opts, err
+	}
+	if opts.Author, err = w.ask("Author", opts.Author, nil); err != nil {
+		return opts, err
+	}
+	license := opts.License
+	if license == "" {
+		license = "MIT"
+	}
+	if opts.License, err = w.ask("License", license, nil); err != nil {
+		return opts, err
+	}
+
+	location := opts.Registry
+	if location == "" {
+		location = "default"
+	}
+	if opts.Registry, err = w.ask("Registry", location, func(answer string) error {
+		_, err := registry.Open(answer, registry.Options{})
+		return err
+	}); err != nil {
+		return opts, err
+	}
+
+	var deps map[string]string
+	if _, err := w.ask("Dependencies (name@range, comma-separated)", "", func(answer string) error {
+		var err error
+		deps, err = parseDependencyList(answer)
+		return err
+	}); err != nil {
+		return opts, err
+	}
+	if len(deps) > 0 {
+		if opts.Dependencies == nil {
+			opts.Dependencies = make(map[string]string)
+		}
+		for name, constraint := range deps {
+			opts.Dependencies[name] = constraint
+		}
+	}
+
+	fmt.Fprintln(out)
+	return opts, nil
+}
+
+// parseDependencyList parses a comma-separated list of name@range
+// dependencies. A name without a range depends on any version.
+func parseDependencyList(list string) (map[string]string, error) {
+	deps := make(map[string]string)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, constraint := item, "*"
+		if i := strings.LastIndex(item, "@"); i > 0 {
+			name, constraint = item[:i], item[i+1:]
+		}
+		if err := pkg.ValidateName(name); err != nil {
+			return nil, err
+		}
+		if _, err := pkg.ParseConstraint(constraint); err != nil {
+			return nil, fmt.Errorf("dependency %s: %w", name, err)
+		}
+		deps[name] = constraint
+	}
+	return deps, nil
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunInitWizard(t *testing.T) {
+	registryDir := t.TempDir()
+	answers := strings.Join([]string{
+		"spreadsheet", // not a kind: asked again
+		"2",           // tool
+		"Bad Name",    // rejected by name validation
+		"",            // accept the default name
+		"one",         // not a version
+		"1.0.0",
+		"Searches the web",
+		"Ada",
+		"",                  // default license
+		"ftp://example.com", // unsupported registry
+		registryDir,
+		"http-tool@^1.0.0, @team/cache@>=2 <3, parser",
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	opts, err := runInitWizard(strings.NewReader(answers), &out, filepath.Join("projects", "web-search"), InitOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, InitOptions{
+		Name:        "web-search",
+		Version:     "1.0.0",
+		Description: "Searches the web",
+		Author:      "Ada",
+		License:     "MIT",
+		Registry:    registryDir,
+		Template:    "tool",
+		Dependencies: map[string]string{
+			"http-tool":   "^1.0.0",
+			"@team/cache": ">=2 <3",
+			"parser":      "*",
+		},
+	}, opts)
+	assert.Contains(t, out.String(), "choose one of agent, tool, chain, prompt-library, dataset, empty")
+	assert.Contains(t, out.String(), `invalid package name "Bad Name"`)
+	assert.Contains(t, out.String(), "invalid semantic version")
+	assert.Contains(t, out.String(), "unsupported registry location")
+}
+
+func TestRunInitWizardDefaults(t *testing.T) {
+	opts, err := runInitWizard(strings.NewReader(strings.Repeat("\n", 8)), &bytes.Buffer{}, "bot",
+		InitOptions{Template: "dataset", Author: "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "dataset", opts.Template)
+	assert.Equal(t, "bot", opts.Name)
+	assert.Equal(t, "0.1.0", opts.Version)
+	assert.Equal(t, "Ada", opts.Author)
+	assert.Equal(t, "default", opts.Registry)
+	assert.Empty(t, opts.Dependencies)
+
+	opts, err = runInitWizard(strings.NewReader("empty\n"+strings.Repeat("\n", 7)), &bytes.Buffer{}, "bot", InitOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, opts.Template)
+}
+
+func TestRunInitWizardEndOfInput(t *testing.T) {
+	_, err := runInitWizard(strings.NewReader("agent\nbot\n"), &bytes.Buffer{}, "bot", InitOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "input ended")
+}
+
+func TestInitProjectInteractiveWithoutTerminal(t *testing.T) {
+	// go test does not run with a terminal on stdin, so init falls back to
+	// the options as given
+	project := filepath.Join(t.TempDir(), "bot")
+	require.NoError(t, InitProject(project, InitOptions{Interactive: true, License: "Apache-2.0"}))
+
+	agentPkg, err := loadPackage(project)
+	require.NoError(t, err)
+	assert.Equal(t, "bot", agentPkg.Name)
+	assert.Equal(t, "Apache-2.0", agentPkg.License)
+}
@@ -0,0 +1,285 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var digestPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// NetworkMode selects when a Cache reads from its registry.
+type NetworkMode int
+
+const (
+	// Online reads metadata from the registry, keeping the cache up to
+	// date. Archives never change, so cached ones are always used.
+	Online NetworkMode = iota
+	// PreferOffline reads from the cache and only goes to the registry
+	// for what is not cached.
+	PreferOffline
+	// Offline only reads from the cache.
+	Offline
+)
+
+// ErrOffline is returned when an operation needs the registry in Offline
+// mode.
+var ErrOffline = errors.New("the registry cannot be reached in offline mode")
+
+// NotCachedError is returned in Offline mode for a package whose metadata,
+// or a version whose archive, is not in the cache. It unwraps to
+// ErrOffline.
+type NotCachedError struct {
+	Name string
+	// Version is set when the archive of the version is missing
+	Version string
+}
+
+func (e *NotCachedError) Error() string {
+	if e.Version == "" {
+		return fmt.Sprintf("%s is not in the offline cache", e.Name)
+	}
+	return fmt.Sprintf("%s@%s is not in the offline cache", e.Name, e.Version)
+}
+
+func (e *NotCachedError) Unwrap() error {
+	return ErrOffline
+}
+
+// DefaultCacheDir returns the directory registry responses are cached in,
+// in the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(cache, "agenthub", "registry"), nil
+}
+
+// Cache is a Registry that keeps the metadata and archives read from
+// another registry on disk, so they can be installed again without the
+// network. The layout is:
+//
+//	metadata/<registry>/<name>.json            PackageInfo, per registry
+//	metadata/<registry>/<name>.etag            its ETag, when the registry sent one
+//	archives/sha256/<first two hex>/<hex>.tgz  archives, by digest
+//
+// where <registry> is derived from the registry location. Archives are
+// only stored once their digest matches the published one.
+type Cache struct {
+	reg  Registry
+	dir  string
+	mode NetworkMode
+}
+
+// NewCache returns a cache of reg in dir, or in DefaultCacheDir when dir is
+// empty.
+func NewCache(reg Registry, dir string, mode NetworkMode) (*Cache, error) {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache directory %q: %w", dir, err)
+	}
+	return &Cache{reg: reg, dir: root, mode: mode}, nil
+}
+
+// conditionalRegistry is a Registry that can tell whether an index read
+// before is still current, so Online mode only transfers indexes that
+// changed.
+type conditionalRegistry interface {
+	// PackageIfChanged returns the metadata of name with its ETag, or nil
+	// metadata when the registry still serves the index tagged etag.
+	PackageIfChanged(name, etag string) (*PackageInfo, string, error)
+}
+
+// Location returns the location of the cached registry.
+func (c *Cache) Location() string {
+	return c.reg.Location()
+}
+
+func (c *Cache) metadataPath(name string) string {
+	sum := sha256.Sum256([]byte(c.reg.Location()))
+	return filepath.Join(c.dir, "metadata", hex.EncodeToString(sum[:8]), filepath.FromSlash(name)+".json")
+}
+
+func (c *Cache) etagPath(name string) string {
+	return strings.TrimSuffix(c.metadataPath(name), ".json") + ".etag"
+}
+
+func (c *Cache) archivePath(digest string) (string, error) {
+	if !digestPattern.MatchString(digest) {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	sum := digest[len("sha256:"):]
+	return filepath.Join(c.dir, "archives", "sha256", sum[:2], sum+".tgz"), nil
+}
+
+// Package returns the metadata of every version of name, from the cache or
+// the registry as the mode selects.
+func (c *Cache) Package(name string) (*PackageInfo, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	if c.mode != Online {
+		info, err := c.cachedPackage(name)
+		if err != nil || info != nil {
+			return info, err
+		}
+		if c.mode == Offline {
+			return nil, &NotCachedError{Name: name}
+		}
+	}
+
+	conditional, ok := c.reg.(conditionalRegistry)
+	if !ok {
+		info, err := c.reg.Package(name)
+		if err != nil {
+			return nil, err
+		}
+		return info, c.store(name, info, "")
+	}
+
+	var etag string
+	cached, err := c.cachedPackage(name)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		if data, err := os.ReadFile(c.etagPath(name)); err == nil {
+			etag = strings.TrimSpace(string(data))
+		}
+	}
+	info, etag, err := conditional.PackageIfChanged(name, etag)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return cached, nil
+	}
+	return info, c.store(name, info, etag)
+}
+
+// store writes the metadata of name and its ETag, if any, to the cache
+func (c *Cache) store(name string, info *PackageInfo, etag string) error {
+	// The old ETag must not outlive the index it belongs to.
+	os.Remove(c.etagPath(name))
+	err := writeFileAtomic(c.metadataPath(name), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(info)
+	})
+	if err == nil && etag != "" {
+		err = writeFileAtomic(c.etagPath(name), func(w io.Writer) error {
+			_, err := io.WriteString(w, etag+"\n")
+			return err
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to cache index of %s: %w", name, err)
+	}
+	return nil
+}
+
+// cachedPackage returns the cached metadata of name, or nil when there is
+// none
+func (c *Cache) cachedPackage(name string) (*PackageInfo, error) {
+	data, err := os.ReadFile(c.metadataPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached index of %s: %w", name, err)
+	}
+	var info PackageInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		// A damaged entry is a cache miss.
+		return nil, nil
+	}
+	if info.Versions == nil {
+		info.Versions = make(map[string]*VersionInfo)
+	}
+	return &info, nil
+}
+
+// Fetch opens the archive of a published version, from the cache when it
+// holds the archive, and stores archives downloaded from the registry.
+func (c *Cache) Fetch(name, version string) (io.ReadCloser, error) {
+	info, err := c.Package(name)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := info.Versions[version]
+	if !ok {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, ErrNotFound)
+	}
+	path, err := c.archivePath(v.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, err)
+	}
+	if f, err := os.Open(path); err == nil {
+		return f, nil
+	}
+	if c.mode == Offline {
+		return nil, &NotCachedError{Name: name, Version: version}
+	}
+
+	rc, err := c.reg.Fetch(name, version)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	err = writeFileAtomic(path, func(w io.Writer) error {
+		hash := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(w, hash), rc); err != nil {
+			return err
+		}
+		if got := "sha256:" + hex.EncodeToString(hash.Sum(nil)); got != v.Digest {
+			return fmt.Errorf("archive digest is %s, but the registry publishes %s", got, v.Digest)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s@%s: %w", name, version, err)
+	}
+	return os.Open(path)
+}
+
+// Archive opens the cached archive with the given digest, or reports false
+// when the cache does not hold it. Unlike Fetch it needs no metadata, so a
+// lockfile pinning the digest is enough to install offline.
+func (c *Cache) Archive(digest string) (io.ReadCloser, bool) {
+	path, err := c.archivePath(digest)
+	if err != nil {
+		return nil, false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	return f, true
+}
+
+// Publish stores a new version in the registry and forgets the cached
+// metadata of the package.
+func (c *Cache) Publish(info *VersionInfo, archive io.Reader) (*VersionInfo, error) {
+	if c.mode == Offline {
+		return nil, ErrOffline
+	}
+	published, err := c.reg.Publish(info, archive)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(c.etagPath(info.Name))
+	os.Remove(c.metadataPath(info.Name))
+	return published, nil
+}
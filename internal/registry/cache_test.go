@@ -0,0 +1,179 @@
+package registry
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyRegistry is a Registry that fails every call while down, like one
+// behind a lost network connection
+type flakyRegistry struct {
+	Registry
+	down  bool
+	calls int
+	// serve replaces the archives served when set
+	serve string
+}
+
+var errNetwork = errors.New("network is unreachable")
+
+func (f *flakyRegistry) Package(name string) (*PackageInfo, error) {
+	f.calls++
+	if f.down {
+		return nil, errNetwork
+	}
+	return f.Registry.Package(name)
+}
+
+func (f *flakyRegistry) Fetch(name, version string) (io.ReadCloser, error) {
+	f.calls++
+	if f.down {
+		return nil, errNetwork
+	}
+	if f.serve != "" {
+		return io.NopCloser(strings.NewReader(f.serve)), nil
+	}
+	return f.Registry.Fetch(name, version)
+}
+
+func fetchString(t *testing.T, reg Registry, name, version string) string {
+	t.Helper()
+	rc, err := reg.Fetch(name, version)
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestCacheModes(t *testing.T) {
+	local, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+	publishString(t, local, "web-search", "1.0.0", "one")
+	remote := &flakyRegistry{Registry: local}
+	dir := t.TempDir()
+
+	online, err := NewCache(remote, dir, Online)
+	require.NoError(t, err)
+	assert.Equal(t, local.Location(), online.Location())
+	info, err := online.Package("web-search")
+	require.NoError(t, err)
+	assert.Len(t, info.Versions, 1)
+	assert.Equal(t, "one", fetchString(t, online, "web-search", "1.0.0"))
+
+	// Offline, everything read before is still there.
+	remote.down = true
+	remote.calls = 0
+	offline, err := NewCache(remote, dir, Offline)
+	require.NoError(t, err)
+	info, err = offline.Package("web-search")
+	require.NoError(t, err)
+	assert.Len(t, info.Versions, 1)
+	assert.Equal(t, "one", fetchString(t, offline, "web-search", "1.0.0"))
+	assert.Zero(t, remote.calls, "offline mode never uses the registry")
+
+	var notCached *NotCachedError
+	_, err = offline.Package("http-tool")
+	require.ErrorAs(t, err, &notCached)
+	assert.EqualError(t, err, "http-tool is not in the offline cache")
+	_, err = offline.Publish(&VersionInfo{Name: "web-search", Version: "2.0.0"}, strings.NewReader("two"))
+	assert.ErrorIs(t, err, ErrOffline)
+
+	// Online, the registry is needed for metadata.
+	_, err = online.Package("web-search")
+	assert.ErrorIs(t, err, errNetwork)
+
+	// Preferring the cache means a new version goes unseen until the
+	// metadata is read online again.
+	remote.down = false
+	publishString(t, local, "web-search", "1.1.0", "newer")
+	preferOffline, err := NewCache(remote, dir, PreferOffline)
+	require.NoError(t, err)
+	info, err = preferOffline.Package("web-search")
+	require.NoError(t, err)
+	assert.Len(t, info.Versions, 1)
+	_, err = preferOffline.Package("missing")
+	assert.ErrorIs(t, err, ErrNotFound, "a cache miss goes to the registry")
+
+	info, err = online.Package("web-search")
+	require.NoError(t, err)
+	assert.Len(t, info.Versions, 2)
+	_, err = offline.Fetch("web-search", "1.1.0")
+	require.ErrorAs(t, err, &notCached)
+	assert.EqualError(t, err, "web-search@1.1.0 is not in the offline cache")
+	assert.Equal(t, "newer", fetchString(t, preferOffline, "web-search", "1.1.0"))
+	assert.Equal(t, "newer", fetchString(t, offline, "web-search", "1.1.0"))
+}
+
+func TestCacheRejectsTamperedArchives(t *testing.T) {
+	local, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+	publishString(t, local, "web-search", "1.0.0", "one")
+	remote := &flakyRegistry{Registry: local, serve: "tampered"}
+	dir := t.TempDir()
+
+	online, err := NewCache(remote, dir, Online)
+	require.NoError(t, err)
+	_, err = online.Fetch("web-search", "1.0.0")
+	assert.ErrorContains(t, err, "failed to download web-search@1.0.0: archive digest is sha256:")
+
+	offline, err := NewCache(remote, dir, Offline)
+	require.NoError(t, err)
+	_, err = offline.Fetch("web-search", "1.0.0")
+	var notCached *NotCachedError
+	assert.ErrorAs(t, err, &notCached, "a tampered archive is not cached")
+}
+
+func TestCachePublishRefreshesMetadata(t *testing.T) {
+	local, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+	publishString(t, local, "web-search", "1.0.0", "one")
+	cache, err := NewCache(local, t.TempDir(), PreferOffline)
+	require.NoError(t, err)
+	_, err = cache.Package("web-search")
+	require.NoError(t, err)
+
+	publishString(t, cache, "web-search", "1.1.0", "newer")
+	info, err := cache.Package("web-search")
+	require.NoError(t, err)
+	assert.Len(t, info.Versions, 2)
+}
+
+func TestCacheRevalidatesWithETag(t *testing.T) {
+	var notModified int32
+	info := &PackageInfo{Name: "tool", Versions: map[string]*VersionInfo{
+		"1.0.0": {Name: "tool", Version: "1.0.0", Digest: "sha256:ab"},
+	}}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		writeJSON(w, http.StatusOK, info)
+	})
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+
+	// Each run opens a new client; the ETag comes from the cache on disk.
+	for i := 0; i < 2; i++ {
+		client, err := NewHTTP(srv.URL, Options{})
+		require.NoError(t, err)
+		cache, err := NewCache(client, dir, Online)
+		require.NoError(t, err)
+		got, err := cache.Package("tool")
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:ab", got.Versions["1.0.0"].Digest)
+	}
+	assert.Equal(t, int32(1), notModified)
+}
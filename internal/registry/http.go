@@ -0,0 +1,430 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnauthorized is returned when the registry rejects the request's
+// credentials or the caller may not access a package.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// DefaultTimeout bounds how long a registry may take to start responding,
+// and how long a response may then stall.
+const DefaultTimeout = 30 * time.Second
+
+// maxRetryAfter bounds the delay a registry may request before a retry.
+const maxRetryAfter = time.Minute
+
+// DefaultRetries is how many times a failed request is retried.
+const DefaultRetries = 3
+
+// HTTPError is an error response from an HTTP registry. It unwraps to
+// ErrNotFound, ErrVersionExists or ErrUnauthorized where one applies.
+type HTTPError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	// RetryAfter is the delay requested by a Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("registry returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("registry returned %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *HTTPError) Unwrap() error {
+	switch {
+	case e.Code == CodeNotFound || e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.Code == CodeVersionExists || e.StatusCode == http.StatusConflict:
+		return ErrVersionExists
+	case e.Code == CodeUnauthorized || e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// Error codes used in the "code" field of error responses.
+const (
+	CodeNotFound       = "not_found"
+	CodeVersionExists  = "version_exists"
+	CodeUnauthorized   = "unauthorized"
+	CodeInvalidRequest = "invalid_request"
+	CodeInternal       = "internal"
+)
+
+// ErrorResponse is the body of every non-2xx response.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes a failed request.
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Options configures how a registry is opened.
+type Options struct {
+	// Token is sent as a bearer token to HTTP registries.
+	Token string
+	// Timeout bounds the wait for each HTTP response and every stall
+	// while one is read; zero means DefaultTimeout.
+	Timeout time.Duration
+	// Network selects when HTTP registries are used rather than the
+	// cache of what was read from them.
+	Network NetworkMode
+	// CacheDir is the directory of that cache; empty selects
+	// DefaultCacheDir.
+	CacheDir string
+}
+
+// HTTP is a client for a registry served over HTTP. The wire protocol is
+// documented in docs/registry-protocol.md.
+type HTTP struct {
+	base    *url.URL
+	client  *http.Client
+	token   string
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+
+	mu    sync.Mutex
+	etags map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+// NewHTTP returns a client for the registry at baseURL.
+func NewHTTP(baseURL string, opts Options) (*HTTP, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid registry URL %q", baseURL)
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	// An overall client timeout would cut off large archives on slow
+	// links, so only the wait for headers and stalls are bounded.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	return &HTTP{
+		base:    base,
+		client:  &http.Client{Transport: transport},
+		token:   opts.Token,
+		timeout: timeout,
+		retries: DefaultRetries,
+		backoff: 250 * time.Millisecond,
+		etags:   make(map[string]cachedResponse),
+	}, nil
+}
+
+// Location returns the registry base URL.
+func (h *HTTP) Location() string {
+	return h.base.String()
+}
+
+func (h *HTTP) endpoint(segments ...string) string {
+	u := *h.base
+	escaped := make([]string, len(segments))
+	for i, s := range segments {
+		escaped[i] = url.PathEscape(s)
+	}
+	u.Path = h.base.Path + "/" + strings.Join(segments, "/")
+	u.RawPath = h.base.EscapedPath() + "/" + strings.Join(escaped, "/")
+	return u.String()
+}
+
+// Package returns the metadata of every version of name. Responses are
+// cached by ETag for the life of the client, so repeated lookups only
+// transfer the index when it has changed.
+func (h *HTTP) Package(name string) (*PackageInfo, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	cached, hasCached := h.etags[name]
+	h.mu.Unlock()
+
+	body, etag, err := h.index(name, cached.etag)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil && hasCached {
+		body = cached.body
+	} else if etag != "" {
+		h.mu.Lock()
+		h.etags[name] = cachedResponse{etag: etag, body: body}
+		h.mu.Unlock()
+	}
+	return parseIndex(name, body)
+}
+
+// PackageIfChanged returns the metadata of name with its ETag, or nil
+// metadata when the registry still serves the index tagged etag. Cache
+// uses it to keep its copy of an index current across runs.
+func (h *HTTP) PackageIfChanged(name, etag string) (*PackageInfo, string, error) {
+	if err := checkName(name); err != nil {
+		return nil, "", err
+	}
+	body, etag, err := h.index(name, etag)
+	if err != nil || body == nil {
+		return nil, etag, err
+	}
+	info, err := parseIndex(name, body)
+	return info, etag, err
+}
+
+// index downloads the index of name and returns it with its ETag. When
+// etag is set and still current the body is nil.
+func (h *HTTP) index(name, etag string) ([]byte, string, error) {
+	endpoint := h.endpoint("v1", "packages", name)
+	resp, err := h.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		if err == nil && etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		return req, err
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("package %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return nil, etag, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read index of %s: %w", name, err)
+	}
+	return body, resp.Header.Get("ETag"), nil
+}
+
+func parseIndex(name string, body []byte) (*PackageInfo, error) {
+	var info PackageInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("invalid index for %s: %w", name, err)
+	}
+	if info.Versions == nil {
+		info.Versions = make(map[string]*VersionInfo)
+	}
+	return &info, nil
+}
+
+// Fetch downloads the archive of a published version. The caller must
+// close the returned reader.
+func (h *HTTP) Fetch(name, version string) (io.ReadCloser, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+
+	endpoint := h.endpoint("v1", "packages", name, version, "archive")
+	resp, err := h.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		if err == nil {
+			req.Header.Set("Accept", "application/octet-stream")
+		}
+		return req, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, err)
+	}
+	return resp.Body, nil
+}
+
+// Publish uploads a new version as a multipart form holding the metadata
+// and the archive.
+func (h *HTTP) Publish(info *VersionInfo, archive io.Reader) (*VersionInfo, error) {
+	if err := checkName(info.Name); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(info.Version); err != nil {
+		return nil, err
+	}
+
+	// The form is buffered so it can be replayed when a request is retried.
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	meta, err := mw.CreateFormField("metadata")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(meta).Encode(info); err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	part, err := mw.CreateFormFile("archive", info.Version+".tgz")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, archive); err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	endpoint := h.endpoint("v1", "packages", info.Name, info.Version)
+	resp, err := h.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(form.Bytes()))
+		if err == nil {
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+		}
+		return req, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s@%s: %w", info.Name, info.Version, err)
+	}
+	defer resp.Body.Close()
+
+	var published VersionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&published); err != nil {
+		return nil, fmt.Errorf("invalid publish response: %w", err)
+	}
+	return &published, nil
+}
+
+// do sends the request built by newRequest, retrying network failures and
+// retryable status codes with exponential backoff. A PUT may have taken
+// effect when it fails that way, so it is only retried after 429 Too Many
+// Requests. Error responses are converted to *HTTPError. The caller closes
+// the body of the returned response, whose status is 2xx or 304.
+func (h *HTTP) do(newRequest func() (*http.Request, error)) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= h.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(h.delay(attempt, lastErr))
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		if req.Header.Get("Accept") == "" {
+			req.Header.Set("Accept", "application/json")
+		}
+		req.Header.Set("User-Agent", "agenthub")
+		if h.token != "" {
+			req.Header.Set("Authorization", "Bearer "+h.token)
+		}
+
+		// The idle timer starts once the headers arrive: the transport
+		// bounds the wait for them, and uploads may take as long as they
+		// keep moving.
+		ctx, cancel := context.WithCancelCause(req.Context())
+		resp, err := h.client.Do(req.WithContext(ctx))
+		if err != nil {
+			cancel(nil)
+			if req.Method == http.MethodPut {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+		body := &idleBody{ReadCloser: resp.Body, cancel: cancel, timeout: h.timeout}
+		body.timer = time.AfterFunc(h.timeout, body.expire)
+		resp.Body = body
+		if resp.StatusCode < 400 {
+			return resp, nil
+		}
+
+		lastErr = decodeError(resp)
+		resp.Body.Close()
+		if !retryable(resp.StatusCode) || (req.Method == http.MethodPut && resp.StatusCode != http.StatusTooManyRequests) {
+			return nil, lastErr
+		}
+	}
+	return nil, lastErr
+}
+
+// errStalled ends a response that stopped arriving for longer than the
+// client timeout.
+var errStalled = errors.New("the registry stopped responding")
+
+// idleBody is a response body that cancels its request when no data
+// arrives for timeout, however long the whole transfer takes.
+type idleBody struct {
+	io.ReadCloser
+	timer   *time.Timer
+	timeout time.Duration
+	cancel  context.CancelCauseFunc
+}
+
+func (b *idleBody) expire() {
+	b.cancel(errStalled)
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	if err != nil && err != io.EOF && !b.timer.Stop() {
+		err = fmt.Errorf("%w: %v", errStalled, err)
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	b.cancel(nil)
+	return b.ReadCloser.Close()
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (h *HTTP) delay(attempt int, lastErr error) time.Duration {
+	var httpErr *HTTPError
+	if errors.As(lastErr, &httpErr) && httpErr.RetryAfter > 0 {
+		return httpErr.RetryAfter
+	}
+	return h.backoff << (attempt - 1)
+}
+
+func decodeError(resp *http.Response) error {
+	httpErr := &HTTPError{StatusCode: resp.StatusCode}
+	var body ErrorResponse
+	if data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); err == nil && json.Unmarshal(data, &body) == nil {
+		httpErr.Code = body.Error.Code
+		httpErr.Message = body.Error.Message
+	}
+
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		httpErr.RetryAfter = maxRetryAfter
+		if secs < int(maxRetryAfter/time.Second) {
+			httpErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+	}
+	return httpErr
+}
@@ -0,0 +1,243 @@
+package registry
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func newTestClient(t *testing.T, handler http.Handler, opts Options) *HTTP {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := NewHTTP(srv.URL, opts)
+	require.NoError(t, err)
+	client.backoff = time.Millisecond
+	return client
+}
+
+func TestHTTPPackage(t *testing.T) {
+	var requests, notModified int32
+	info := &PackageInfo{Name: "@team/tool", Versions: map[string]*VersionInfo{
+		"1.0.0": {Name: "@team/tool", Version: "1.0.0", Digest: "sha256:ab"},
+	}}
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "/v1/packages/@team%2Ftool", r.URL.EscapedPath())
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		writeJSON(w, http.StatusOK, info)
+	}), Options{})
+
+	for i := 0; i < 2; i++ {
+		got, err := client.Package("@team/tool")
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:ab", got.Versions["1.0.0"].Digest)
+	}
+	assert.Equal(t, int32(2), requests)
+	assert.Equal(t, int32(1), notModified, "the second lookup is served from the ETag cache")
+}
+
+func TestHTTPFetch(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/packages/tool/1.0.0/archive", r.URL.Path)
+		assert.Equal(t, "application/octet-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "application/gzip")
+		io.WriteString(w, "archive bytes")
+	}), Options{})
+
+	rc, err := client.Fetch("tool", "1.0.0")
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "archive bytes", string(data))
+}
+
+func TestHTTPPublish(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/v1/packages/tool/1.0.0", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var meta VersionInfo
+		require.NoError(t, json.Unmarshal([]byte(r.FormValue("metadata")), &meta))
+		f, _, err := r.FormFile("archive")
+		require.NoError(t, err)
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+
+		meta.Size = int64(len(data))
+		meta.Digest = "sha256:computed"
+		writeJSON(w, http.StatusCreated, meta)
+	}), Options{Token: "secret"})
+
+	published, err := client.Publish(&VersionInfo{Name: "tool", Version: "1.0.0", Private: true}, strings.NewReader("archive"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), published.Size)
+	assert.Equal(t, "sha256:computed", published.Digest)
+	assert.True(t, published.Private)
+}
+
+func TestHTTPPublishRetries(t *testing.T) {
+	// A publish that failed with 503 may have been stored, so it is not
+	// retried; 429 means the registry did not take it.
+	for status, want := range map[int]int32{http.StatusServiceUnavailable: 1, http.StatusTooManyRequests: DefaultRetries + 1} {
+		var attempts int32
+		client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(status)
+		}), Options{})
+		_, err := client.Publish(&VersionInfo{Name: "tool", Version: "1.0.0"}, strings.NewReader("archive"))
+		assert.Error(t, err)
+		assert.Equal(t, want, attempts, "status %d", status)
+	}
+}
+
+func TestHTTPSlowUpload(t *testing.T) {
+	// The registry reads the upload late, so sending it takes longer than
+	// the timeout, which only covers waiting for the response.
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		io.Copy(io.Discard, r.Body)
+		writeJSON(w, http.StatusCreated, VersionInfo{Name: "tool", Version: "1.0.0"})
+	}), Options{Timeout: 50 * time.Millisecond})
+	client.retries = 0
+
+	archive := strings.Repeat("x", 32<<20)
+	_, err := client.Publish(&VersionInfo{Name: "tool", Version: "1.0.0"}, strings.NewReader(archive))
+	assert.NoError(t, err)
+}
+
+func TestHTTPErrorMapping(t *testing.T) {
+	testCases := []struct {
+		status int
+		code   string
+		target error
+	}{
+		{http.StatusNotFound, CodeNotFound, ErrNotFound},
+		{http.StatusConflict, CodeVersionExists, ErrVersionExists},
+		{http.StatusUnauthorized, CodeUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, "", ErrUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, tc.status, ErrorResponse{Error: ErrorBody{Code: tc.code, Message: "nope"}})
+			}), Options{})
+
+			_, err := client.Package("tool")
+			assert.ErrorIs(t, err, tc.target)
+
+			var httpErr *HTTPError
+			require.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, tc.status, httpErr.StatusCode)
+			assert.Contains(t, err.Error(), "nope")
+		})
+	}
+}
+
+func TestHTTPRetries(t *testing.T) {
+	var attempts int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Error: ErrorBody{Code: CodeInternal}})
+			return
+		}
+		writeJSON(w, http.StatusOK, PackageInfo{Name: "tool"})
+	}), Options{})
+
+	_, err := client.Package("tool")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), attempts)
+
+	// Client errors are not retried.
+	atomic.StoreInt32(&attempts, 0)
+	client = newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: ErrorBody{Code: CodeInvalidRequest}})
+	}), Options{})
+	_, err = client.Package("tool")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), attempts)
+
+	// Persistent failures give up after the configured retries.
+	atomic.StoreInt32(&attempts, 0)
+	client = newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}), Options{})
+	_, err = client.Package("tool")
+	assert.ErrorContains(t, err, "502 Bad Gateway")
+	assert.Equal(t, int32(DefaultRetries+1), attempts)
+}
+
+func TestHTTPTimeout(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}), Options{Timeout: 10 * time.Millisecond})
+	client.retries = 0
+
+	_, err := client.Package("tool")
+	assert.Error(t, err)
+}
+
+func TestHTTPStalledDownload(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A slow download that keeps arriving outlasts the timeout.
+		for i := 0; i < 5; i++ {
+			io.WriteString(w, "chunk ")
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+		if strings.Contains(r.URL.Path, "/2.0.0/") {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}), Options{Timeout: 60 * time.Millisecond})
+	client.retries = 0
+
+	assert.Equal(t, strings.Repeat("chunk ", 5), fetchString(t, client, "tool", "1.0.0"))
+
+	rc, err := client.Fetch("tool", "2.0.0")
+	require.NoError(t, err)
+	defer rc.Close()
+	_, err = io.ReadAll(rc)
+	assert.ErrorIs(t, err, errStalled)
+}
+
+func TestRetryAfterIsBounded(t *testing.T) {
+	for header, want := range map[string]time.Duration{"2": 2 * time.Second, "86400": maxRetryAfter, "soon": 0} {
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {header}},
+			Body: io.NopCloser(strings.NewReader(""))}
+		var httpErr *HTTPError
+		require.ErrorAs(t, decodeError(resp), &httpErr)
+		assert.Equal(t, want, httpErr.RetryAfter, header)
+	}
+}
+
+func TestNewHTTPInvalidURL(t *testing.T) {
+	for _, u := range []string{"ftp://host", "http://", "://bad"} {
+		_, err := NewHTTP(u, Options{})
+		assert.Error(t, err, u)
+	}
+}
@@ -0,0 +1,268 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Local is a registry stored in a directory, suitable for a network share
+// or fully offline use. The layout is:
+//
+//	index/<name>.json               PackageInfo for every version of <name>
+//	archives/<name>/<version>.tgz   package archives
+//
+// Scoped names such as @team/tool become nested directories. Files are
+// written to a temporary name and renamed into place, so readers never see
+// a partial index or archive, and publishers take index/<name>.json.lock
+// so two publishes of a package cannot lose each other's version.
+type Local struct {
+	root string
+}
+
+// NewLocal returns the local registry rooted at dir. The directory is
+// created on first publish.
+func NewLocal(dir string) (*Local, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry directory %q: %w", dir, err)
+	}
+	return &Local{root: root}, nil
+}
+
+// Location returns the absolute path of the registry directory.
+func (l *Local) Location() string {
+	return l.root
+}
+
+func (l *Local) indexPath(name string) string {
+	return filepath.Join(l.root, "index", filepath.FromSlash(name)+".json")
+}
+
+func (l *Local) archivePath(name, version string) string {
+	return filepath.Join(l.root, "archives", filepath.FromSlash(name), version+".tgz")
+}
+
+// Package returns the metadata of every version of name.
+func (l *Local) Package(name string) (*PackageInfo, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(l.indexPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("package %s: %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index of %s: %w", name, err)
+	}
+
+	var info PackageInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("corrupt index for %s: %w", name, err)
+	}
+	if info.Versions == nil {
+		info.Versions = make(map[string]*VersionInfo)
+	}
+	return &info, nil
+}
+
+// List returns the names of every package in the registry, sorted.
+func (l *Local) List() ([]string, error) {
+	indexDir := filepath.Join(l.root, "index")
+	var names []string
+	err := filepath.WalkDir(indexDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		rel, err := filepath.Rel(indexDir, strings.TrimSuffix(path, ".json"))
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Fetch opens the archive of a published version.
+func (l *Local) Fetch(name, version string) (io.ReadCloser, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(l.archivePath(name, version))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive of %s@%s: %w", name, version, err)
+	}
+	return f, nil
+}
+
+// Publish copies the archive into the registry and adds the version to the
+// package index.
+func (l *Local) Publish(info *VersionInfo, archive io.Reader) (*VersionInfo, error) {
+	if err := checkName(info.Name); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(info.Version); err != nil {
+		return nil, err
+	}
+
+	unlock, err := l.lockIndex(info.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	index, err := l.Package(info.Name)
+	if errors.Is(err, ErrNotFound) {
+		index = &PackageInfo{Name: info.Name, Versions: make(map[string]*VersionInfo)}
+	} else if err != nil {
+		return nil, err
+	}
+	if _, ok := index.Versions[info.Version]; ok {
+		return nil, fmt.Errorf("%s@%s: %w", info.Name, info.Version, ErrVersionExists)
+	}
+
+	published := *info
+	hash := sha256.New()
+	var size int64
+	err = writeFileAtomic(l.archivePath(info.Name, info.Version), func(w io.Writer) error {
+		size, err = io.Copy(io.MultiWriter(w, hash), archive)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store archive of %s@%s: %w", info.Name, info.Version, err)
+	}
+	published.Digest = "sha256:" + hex.EncodeToString(hash.Sum(nil))
+	published.Size = size
+	published.Published = time.Now().UTC().Truncate(time.Second)
+
+	index.Versions[info.Version] = &published
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode index of %s: %w", info.Name, err)
+	}
+	err = writeFileAtomic(l.indexPath(info.Name), func(w io.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update index of %s: %w", info.Name, err)
+	}
+	return &published, nil
+}
+
+// lockTimeout bounds how long Publish waits for another publish of the same
+// package; a lock older than staleLockAge was left by one that crashed.
+const (
+	lockTimeout  = 30 * time.Second
+	staleLockAge = 2 * time.Minute
+)
+
+// lockRefresh is how often the holder of a lock touches it, so a long
+// publish never looks stale
+var lockRefresh = staleLockAge / 4
+
+// lockIndex takes the lock on the index of name and returns the function
+// releasing it. The lock is a file created exclusively, which works on
+// network shares where advisory locks may not; its holder keeps its
+// modification time fresh until it is released.
+func (l *Local) lockIndex(name string) (func(), error) {
+	path := l.indexPath(name) + ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to lock index of %s: %w", name, err)
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			f.Close()
+			return refreshLock(path), nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock index of %s: %w", name, err)
+		}
+		if stat, err := os.Stat(path); err == nil && time.Since(stat.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("index of %s is locked by another publish; remove %s if none is running", name, path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// refreshLock touches the lock file at path every lockRefresh until the
+// returned function removes it
+func refreshLock(path string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lockRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				now := time.Now()
+				os.Chtimes(path, now, now)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		os.Remove(path)
+	}
+}
+
+// writeFileAtomic writes path through a temporary file in the same
+// directory and renames it into place once write succeeds.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishString(t *testing.T, reg Registry, name, version, content string) *VersionInfo {
+	t.Helper()
+	info, err := reg.Publish(&VersionInfo{Name: name, Version: version}, strings.NewReader(content))
+	require.NoError(t, err)
+	return info
+}
+
+func TestLocalPublishAndFetch(t *testing.T) {
+	reg, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	info, err := reg.Publish(&VersionInfo{
+		Name:         "web-search",
+		Version:      "1.0.0",
+		Dependencies: map[string]string{"http-tool": "^1.0.0"},
+	}, strings.NewReader("archive"))
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte("archive"))
+	assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), info.Digest)
+	assert.Equal(t, int64(len("archive")), info.Size)
+	assert.False(t, info.Published.IsZero())
+
+	pkgInfo, err := reg.Package("web-search")
+	require.NoError(t, err)
+	assert.Equal(t, "web-search", pkgInfo.Name)
+	assert.Equal(t, info, pkgInfo.Versions["1.0.0"])
+
+	rc, err := reg.Fetch("web-search", "1.0.0")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, rc.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(data))
+
+	publishString(t, reg, "web-search", "1.1.0", "newer")
+	pkgInfo, err = reg.Package("web-search")
+	require.NoError(t, err)
+	assert.Len(t, pkgInfo.Versions, 2)
+}
+
+func TestLocalScopedName(t *testing.T) {
+	dir := t.TempDir()
+	reg, err := NewLocal(dir)
+	require.NoError(t, err)
+
+	publishString(t, reg, "@team/tool", "1.0.0", "scoped")
+	assert.FileExists(t, filepath.Join(dir, "index", "@team", "tool.json"))
+	assert.FileExists(t, filepath.Join(dir, "archives", "@team", "tool", "1.0.0.tgz"))
+
+	_, err = reg.Package("@team/tool")
+	assert.NoError(t, err)
+}
+
+func TestLocalErrors(t *testing.T) {
+	reg, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = reg.Package("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = reg.Fetch("missing", "1.0.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	publishString(t, reg, "tool", "1.0.0", "a")
+	_, err = reg.Publish(&VersionInfo{Name: "tool", Version: "1.0.0"}, strings.NewReader("b"))
+	assert.ErrorIs(t, err, ErrVersionExists)
+
+	for _, name := range []string{"../escape", "Tool", "a/b", "@scope/../x", ""} {
+		_, err := reg.Publish(&VersionInfo{Name: name, Version: "1.0.0"}, strings.NewReader("x"))
+		assert.Error(t, err, name)
+	}
+	_, err = reg.Publish(&VersionInfo{Name: "tool", Version: "not-semver"}, strings.NewReader("x"))
+	assert.Error(t, err)
+	_, err = reg.Fetch("tool", "../../index/tool")
+	assert.Error(t, err)
+}
+
+func TestLocalLeavesNoTemporaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	reg, err := NewLocal(dir)
+	require.NoError(t, err)
+	publishString(t, reg, "tool", "1.0.0", "a")
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		require.NoError(t, err)
+		assert.NotContains(t, info.Name(), ".tmp", path)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestLocalConcurrentPublishes(t *testing.T) {
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Separate processes share nothing but the directory.
+			reg, err := NewLocal(dir)
+			require.NoError(t, err)
+			_, err = reg.Publish(&VersionInfo{Name: "tool", Version: fmt.Sprintf("1.0.%d", i)}, strings.NewReader("a"))
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	reg, err := NewLocal(dir)
+	require.NoError(t, err)
+	info, err := reg.Package("tool")
+	require.NoError(t, err)
+	assert.Len(t, info.Versions, 20, "no publish loses another's version")
+	assert.NoFileExists(t, reg.indexPath("tool")+".lock")
+
+	// A lock left by a crashed publish is taken over once stale.
+	lock := reg.indexPath("tool") + ".lock"
+	require.NoError(t, os.WriteFile(lock, nil, 0644))
+	old := time.Now().Add(-2 * staleLockAge)
+	require.NoError(t, os.Chtimes(lock, old, old))
+	publishString(t, reg, "tool", "2.0.0", "b")
+}
+
+func TestLocalLockStaysFresh(t *testing.T) {
+	defer func(refresh time.Duration) { lockRefresh = refresh }(lockRefresh)
+	lockRefresh = 10 * time.Millisecond
+	reg, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	unlock, err := reg.lockIndex("tool")
+	require.NoError(t, err)
+	// A publish that has held the lock for long still owns it.
+	lock := reg.indexPath("tool") + ".lock"
+	old := time.Now().Add(-2 * staleLockAge)
+	require.NoError(t, os.Chtimes(lock, old, old))
+	assert.Eventually(t, func() bool {
+		stat, err := os.Stat(lock)
+		return err == nil && time.Since(stat.ModTime()) < staleLockAge
+	}, time.Second, 5*time.Millisecond)
+
+	unlock()
+	assert.NoFileExists(t, lock)
+}
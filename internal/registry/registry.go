@@ -0,0 +1,199 @@
+// Package registry implements the storage backends packages are published
+// to and installed from.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"agenthub/pkg"
+)
+
+var (
+	// ErrNotFound is returned when a package or version does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrVersionExists is returned when publishing a version that is
+	// already in the registry.
+	ErrVersionExists = errors.New("version already published")
+)
+
+// VersionInfo describes one published version of a package.
+type VersionInfo struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Description  string            `json:"description,omitempty"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	// Digest is the SHA-256 of the archive, formatted as "sha256:<hex>".
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Private   bool      `json:"private,omitempty"`
+	Published time.Time `json:"published"`
+}
+
+// PackageInfo lists every published version of a package.
+type PackageInfo struct {
+	Name     string                  `json:"name"`
+	Versions map[string]*VersionInfo `json:"versions"`
+}
+
+// Registry stores package archives and the metadata needed to resolve them.
+type Registry interface {
+	// Location identifies the registry in lockfiles and messages.
+	Location() string
+	// Package returns the metadata of every version of a package.
+	Package(name string) (*PackageInfo, error)
+	// Fetch opens the archive of a published version.
+	Fetch(name, version string) (io.ReadCloser, error)
+	// Publish stores a new version. Digest, Size and Published are filled
+	// in by the registry from the archive; the stored metadata is returned.
+	Publish(info *VersionInfo, archive io.Reader) (*VersionInfo, error)
+}
+
+// DefaultLocation returns the registry used when none is configured: a
+// local registry in the user's home directory.
+func DefaultLocation() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".agenthub", "registry"), nil
+}
+
+// Open returns the registry at location. An empty location or "default"
+// selects DefaultLocation. http:// and https:// URLs select an HTTP
+// registry, read through a Cache in opts.Network mode; anything else is a
+// directory path, optionally written as a file:// URL, which needs no
+// cache to work offline.
+func Open(location string, opts Options) (Registry, error) {
+	if location == "" || location == "default" {
+		var err error
+		if location, err = DefaultLocation(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		h, err := NewHTTP(location, opts)
+		if err != nil {
+			return nil, err
+		}
+		return NewCache(h, opts.CacheDir, opts.Network)
+	case strings.HasPrefix(location, "file://"):
+		return NewLocal(strings.TrimPrefix(location, "file://"))
+	case strings.Contains(location, "://"):
+		return nil, fmt.Errorf("unsupported registry location %q", location)
+	}
+	return NewLocal(location)
+}
+
+// checkName rejects names that cannot be stored safely, such as names
+// containing path separators or "..".
+func checkName(name string) error {
+	return pkg.ValidateName(name)
+}
+
+func checkVersion(version string) error {
+	if _, err := pkg.ParseVersion(version); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Source adapts a Registry to the interfaces used by the solver and the
+// installer. Package metadata is fetched once per name and cached.
+type Source struct {
+	reg   Registry
+	cache map[string]*PackageInfo
+}
+
+// NewSource returns a Source reading from reg.
+func NewSource(reg Registry) *Source {
+	return &Source{reg: reg, cache: make(map[string]*PackageInfo)}
+}
+
+// Registry returns the registry the source reads from.
+func (s *Source) Registry() Registry {
+	return s.reg
+}
+
+// Location returns the location of the underlying registry.
+func (s *Source) Location() string {
+	return s.reg.Location()
+}
+
+func (s *Source) pkgInfo(name string) (*PackageInfo, error) {
+	if info, ok := s.cache[name]; ok {
+		return info, nil
+	}
+	info, err := s.reg.Package(name)
+	if err != nil {
+		return nil, err
+	}
+	s.cache[name] = info
+	return info, nil
+}
+
+// Info returns the metadata of one version.
+func (s *Source) Info(name, version string) (*VersionInfo, error) {
+	info, err := s.pkgInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := info.Versions[version]
+	if !ok {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, ErrNotFound)
+	}
+	return v, nil
+}
+
+// Versions lists the published versions of a package.
+func (s *Source) Versions(name string) ([]string, error) {
+	info, err := s.pkgInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0, len(info.Versions))
+	for v := range info.Versions {
+		versions = append(versions, v)
+	}
+	pkg.SortVersions(versions)
+	return versions, nil
+}
+
+// Dependencies returns the dependency ranges of one version.
+func (s *Source) Dependencies(name, version string) (map[string]string, error) {
+	v, err := s.Info(name, version)
+	if err != nil {
+		return nil, err
+	}
+	return v.Dependencies, nil
+}
+
+// Digest returns the archive digest of one version.
+func (s *Source) Digest(name, version string) (string, error) {
+	v, err := s.Info(name, version)
+	if err != nil {
+		return "", err
+	}
+	return v.Digest, nil
+}
+
+// Fetch opens the archive of one version.
+func (s *Source) Fetch(name, version string) (io.ReadCloser, error) {
+	return s.reg.Fetch(name, version)
+}
+
+// Archive opens the archive with the given digest when the registry is a
+// Cache holding it, and reports false otherwise.
+func (s *Source) Archive(digest string) (io.ReadCloser, bool) {
+	if c, ok := s.reg.(*Cache); ok {
+		return c.Archive(digest)
+	}
+	return nil, false
+}
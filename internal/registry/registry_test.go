@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+
+	reg, err := Open(dir, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, dir, reg.Location())
+
+	reg, err = Open("file://"+dir, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, dir, reg.Location())
+
+	t.Setenv("HOME", dir)
+	reg, err = Open("default", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".agenthub", "registry"), reg.Location())
+
+	reg, err = Open("https://registry.example.com/", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://registry.example.com", reg.Location())
+
+	_, err = Open("ftp://example.com/registry", Options{})
+	assert.ErrorContains(t, err, "unsupported registry location")
+}
+
+func TestSource(t *testing.T) {
+	reg, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+	for _, v := range []string{"1.10.0", "1.2.0", "1.9.0"} {
+		publishString(t, reg, "tool", v, v)
+	}
+	src := NewSource(reg)
+	assert.Equal(t, reg.Location(), src.Location())
+
+	versions, err := src.Versions("tool")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.2.0", "1.9.0", "1.10.0"}, versions)
+
+	digest, err := src.Digest("tool", "1.9.0")
+	require.NoError(t, err)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", digest)
+
+	_, err = src.Dependencies("tool", "2.0.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = src.Versions("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,284 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"agenthub/pkg"
+)
+
+// MaxUploadSize bounds the size of a publish request.
+const MaxUploadSize = 256 << 20
+
+// PackageSummary describes a package in listing and search results.
+type PackageSummary struct {
+	Name        string `json:"name"`
+	Latest      string `json:"latest"`
+	Description string `json:"description,omitempty"`
+	Private     bool   `json:"private,omitempty"`
+}
+
+// PackageList is the body of the listing and search endpoints.
+type PackageList struct {
+	Packages []PackageSummary `json:"packages"`
+}
+
+// ServerOptions configures a registry server.
+type ServerOptions struct {
+	// Tokens are the bearer tokens accepted for publishing and for reading
+	// private packages. With no tokens, publishing is disabled.
+	Tokens []string
+}
+
+// Server serves a Local registry over the HTTP protocol described in
+// docs/registry-protocol.md. Private versions are hidden from clients that
+// do not present a valid token.
+type Server struct {
+	store  *Local
+	tokens []string
+	// mu serialises publishes, which rewrite the package index.
+	mu sync.Mutex
+}
+
+// NewServer returns a server for store.
+func NewServer(store *Local, opts ServerOptions) *Server {
+	return &Server{store: store, tokens: opts.Tokens}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Private versions are only served to clients with a token, so caches
+	// must not share a response between clients.
+	w.Header().Set("Vary", "Authorization")
+	segments, err := pathSegments(r.URL.EscapedPath())
+	if err != nil || len(segments) < 2 || segments[0] != "v1" {
+		writeError(w, http.StatusNotFound, CodeNotFound, "no such endpoint")
+		return
+	}
+
+	switch {
+	case len(segments) == 2 && segments[1] == "packages" && r.Method == http.MethodGet:
+		s.search(w, r, "")
+	case len(segments) == 2 && segments[1] == "search" && r.Method == http.MethodGet:
+		s.search(w, r, r.URL.Query().Get("q"))
+	case len(segments) == 3 && segments[1] == "packages" && r.Method == http.MethodGet:
+		s.getPackage(w, r, segments[2])
+	case len(segments) == 4 && segments[1] == "packages" && r.Method == http.MethodPut:
+		s.publish(w, r, segments[2], segments[3])
+	case len(segments) == 5 && segments[1] == "packages" && segments[4] == "archive" && r.Method == http.MethodGet:
+		s.fetch(w, r, segments[2], segments[3])
+	case len(segments) <= 5 && segments[1] == "packages":
+		writeError(w, http.StatusMethodNotAllowed, CodeInvalidRequest, "method not allowed")
+	default:
+		writeError(w, http.StatusNotFound, CodeNotFound, "no such endpoint")
+	}
+}
+
+// pathSegments splits an escaped URL path and unescapes each segment, so
+// that an encoded scoped name like @team%2Ftool stays one segment.
+func pathSegments(escaped string) ([]string, error) {
+	parts := strings.Split(strings.Trim(escaped, "/"), "/")
+	for i, p := range parts {
+		s, err := url.PathUnescape(p)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = s
+	}
+	return parts, nil
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// visible returns the versions of info the request may see, or nil when
+// it may see none.
+func (s *Server) visible(r *http.Request, info *PackageInfo) *PackageInfo {
+	authorized := s.authorized(r)
+	filtered := &PackageInfo{Name: info.Name, Versions: make(map[string]*VersionInfo)}
+	for v, vi := range info.Versions {
+		if !vi.Private || authorized {
+			filtered.Versions[v] = vi
+		}
+	}
+	if len(filtered.Versions) == 0 {
+		return nil
+	}
+	return filtered
+}
+
+func (s *Server) getPackage(w http.ResponseWriter, r *http.Request, name string) {
+	info, ok := s.lookup(w, r, name)
+	if !ok {
+		return
+	}
+
+	body, err := json.Marshal(info)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// lookup loads the visible versions of a package, writing an error
+// response and returning false when there are none.
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request, name string) (*PackageInfo, bool) {
+	info, err := s.store.Package(name)
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("package %s not found", name))
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return nil, false
+	}
+	if info = s.visible(r, info); info == nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("package %s not found", name))
+		return nil, false
+	}
+	return info, true
+}
+
+func (s *Server) fetch(w http.ResponseWriter, r *http.Request, name, version string) {
+	info, ok := s.lookup(w, r, name)
+	if !ok {
+		return
+	}
+	if _, ok := info.Versions[version]; !ok {
+		writeError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("%s@%s not found", name, version))
+		return
+	}
+
+	rc, err := s.store.Fetch(name, version)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	defer rc.Close()
+	w.Header().Set("Content-Type", "application/gzip")
+	io.Copy(w, rc)
+}
+
+func (s *Server) publish(w http.ResponseWriter, r *http.Request, name, version string) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, "publishing requires a valid token")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUploadSize)
+	var meta VersionInfo
+	if err := json.Unmarshal([]byte(r.FormValue("metadata")), &meta); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "invalid metadata: "+err.Error())
+		return
+	}
+	if meta.Name != name || meta.Version != version {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest,
+			fmt.Sprintf("metadata is for %s@%s but the URL names %s@%s", meta.Name, meta.Version, name, version))
+		return
+	}
+	archive, _, err := r.FormFile("archive")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "missing archive: "+err.Error())
+		return
+	}
+	defer archive.Close()
+
+	s.mu.Lock()
+	published, err := s.store.Publish(&meta, archive)
+	s.mu.Unlock()
+	switch {
+	case errors.Is(err, ErrVersionExists):
+		writeError(w, http.StatusConflict, CodeVersionExists, err.Error())
+		return
+	case err != nil && (checkName(name) != nil || checkVersion(version) != nil):
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(published)
+}
+
+// search lists the visible packages whose name or latest description
+// contains query, case-insensitively. An empty query lists everything.
+func (s *Server) search(w http.ResponseWriter, r *http.Request, query string) {
+	names, err := s.store.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	query = strings.ToLower(query)
+	list := PackageList{Packages: []PackageSummary{}}
+	for _, name := range names {
+		info, err := s.store.Package(name)
+		if err != nil {
+			continue
+		}
+		if info = s.visible(r, info); info == nil {
+			continue
+		}
+
+		versions := make([]string, 0, len(info.Versions))
+		for v := range info.Versions {
+			versions = append(versions, v)
+		}
+		pkg.SortVersions(versions)
+		// Report what installing the package would pick; a package with only
+		// prereleases reports the newest of them.
+		version, err := pkg.ResolveVersion("latest", versions)
+		if err != nil {
+			version = versions[len(versions)-1]
+		}
+		latest := info.Versions[version]
+
+		summary := PackageSummary{Name: name, Latest: latest.Version, Description: latest.Description, Private: latest.Private}
+		if query == "" || strings.Contains(strings.ToLower(name), query) || strings.Contains(strings.ToLower(summary.Description), query) {
+			list.Packages = append(list.Packages, summary)
+		}
+	}
+	sort.Slice(list.Packages, func(i, j int) bool {
+		return list.Packages[i].Name < list.Packages[j].Name
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: ErrorBody{Code: code, Message: message}})
+}
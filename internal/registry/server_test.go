@@ -0,0 +1,173 @@
+package registry
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+	srv := httptest.NewServer(NewServer(store, ServerOptions{Tokens: []string{"secret"}}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func clientFor(t *testing.T, srv *httptest.Server, token string) *HTTP {
+	t.Helper()
+	client, err := NewHTTP(srv.URL, Options{Token: token})
+	require.NoError(t, err)
+	client.retries = 0
+	return client
+}
+
+func getList(t *testing.T, srv *httptest.Server, path, token string) []PackageSummary {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "Authorization", resp.Header.Get("Vary"))
+
+	var list PackageList
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	return list.Packages
+}
+
+func TestServerPublishAndInstall(t *testing.T) {
+	srv := newTestServer(t)
+	publisher := clientFor(t, srv, "secret")
+
+	published, err := publisher.Publish(&VersionInfo{
+		Name:         "@team/web-search",
+		Version:      "1.0.0",
+		Description:  "Search the web",
+		Dependencies: map[string]string{"http-tool": "^1.0.0"},
+	}, strings.NewReader("archive"))
+	require.NoError(t, err)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", published.Digest)
+
+	reader := clientFor(t, srv, "")
+	info, err := reader.Package("@team/web-search")
+	require.NoError(t, err)
+	assert.Equal(t, published.Digest, info.Versions["1.0.0"].Digest)
+	assert.Equal(t, map[string]string{"http-tool": "^1.0.0"}, info.Versions["1.0.0"].Dependencies)
+
+	// The second lookup revalidates with the ETag.
+	_, err = reader.Package("@team/web-search")
+	require.NoError(t, err)
+
+	rc, err := reader.Fetch("@team/web-search", "1.0.0")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "archive", string(data))
+
+	_, err = publisher.Publish(&VersionInfo{Name: "@team/web-search", Version: "1.0.0"}, strings.NewReader("again"))
+	assert.ErrorIs(t, err, ErrVersionExists)
+}
+
+func TestServerRequiresTokenToPublish(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, token := range []string{"", "wrong"} {
+		_, err := clientFor(t, srv, token).Publish(&VersionInfo{Name: "tool", Version: "1.0.0"}, strings.NewReader("x"))
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	}
+}
+
+func TestServerPrivatePackages(t *testing.T) {
+	srv := newTestServer(t)
+	publisher := clientFor(t, srv, "secret")
+	_, err := publisher.Publish(&VersionInfo{Name: "internal-agent", Version: "1.0.0", Private: true}, strings.NewReader("x"))
+	require.NoError(t, err)
+	_, err = publisher.Publish(&VersionInfo{Name: "public-tool", Version: "1.0.0"}, strings.NewReader("y"))
+	require.NoError(t, err)
+
+	anonymous := clientFor(t, srv, "")
+	_, err = anonymous.Package("internal-agent")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = anonymous.Fetch("internal-agent", "1.0.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	info, err := publisher.Package("internal-agent")
+	require.NoError(t, err)
+	assert.True(t, info.Versions["1.0.0"].Private)
+
+	assert.Equal(t, []PackageSummary{{Name: "public-tool", Latest: "1.0.0"}}, getList(t, srv, "/v1/packages", ""))
+	assert.Len(t, getList(t, srv, "/v1/packages", "secret"), 2)
+}
+
+func TestServerSearch(t *testing.T) {
+	srv := newTestServer(t)
+	publisher := clientFor(t, srv, "secret")
+	for _, vi := range []*VersionInfo{
+		{Name: "web-search", Version: "1.0.0", Description: "Old"},
+		{Name: "web-search", Version: "1.2.0", Description: "Search the web"},
+		{Name: "web-search", Version: "2.0.0-beta.1", Description: "Search the web, faster"},
+		{Name: "summarizer", Version: "0.1.0", Description: "Summarize long documents"},
+		{Name: "nightly", Version: "0.1.0-rc.1", Description: "Nightly builds"},
+	} {
+		_, err := publisher.Publish(vi, strings.NewReader(vi.Version))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []PackageSummary{{Name: "web-search", Latest: "1.2.0", Description: "Search the web"}},
+		getList(t, srv, "/v1/search?q=SEARCH", ""))
+	assert.Equal(t, []string{"summarizer"}, summaryNames(getList(t, srv, "/v1/search?q=documents", "")))
+	assert.Equal(t, []string{"nightly", "summarizer", "web-search"}, summaryNames(getList(t, srv, "/v1/search", "")))
+	assert.Equal(t, []PackageSummary{{Name: "nightly", Latest: "0.1.0-rc.1", Description: "Nightly builds"}},
+		getList(t, srv, "/v1/search?q=nightly", ""), "a package with only prereleases reports the newest")
+	assert.Empty(t, getList(t, srv, "/v1/search?q=nothing", ""))
+}
+
+func summaryNames(list []PackageSummary) []string {
+	var names []string
+	for _, s := range list {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestServerRejectsBadRequests(t *testing.T) {
+	srv := newTestServer(t)
+
+	// A publish without a metadata part.
+	req, err := http.NewRequest(http.MethodPut, srv.URL+"/v1/packages/tool/1.0.0", strings.NewReader(""))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	for path, status := range map[string]int{
+		"/":                       http.StatusNotFound,
+		"/v2/packages":            http.StatusNotFound,
+		"/v1/packages/missing":    http.StatusNotFound,
+		"/v1/packages/Bad%20Name": http.StatusBadRequest,
+	} {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, status, resp.StatusCode, path)
+	}
+
+	resp, err = http.Post(srv.URL+"/v1/packages/tool", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
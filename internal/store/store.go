@@ -0,0 +1,249 @@
+// Package store keeps installed packages in a content-addressable store
+// shared by every project on the machine, so each package archive is
+// downloaded and unpacked once and projects link to it.
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"agenthub/internal/archive"
+)
+
+var digestPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// Store is a directory of unpacked package archives keyed by the SHA-256
+// digest of the archive. The layout is:
+//
+//	sha256/<first two hex digits>/<hex digest>/   unpacked package files
+//
+// Packages are unpacked into a temporary directory and renamed into
+// place, so a package in the store is always complete.
+type Store struct {
+	root string
+}
+
+// DefaultDir returns the store used when none is configured, in the
+// user's cache directory.
+func DefaultDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(cache, "agenthub", "store"), nil
+}
+
+// Open returns the store in dir, or in DefaultDir when dir is empty. The
+// directory is created when the first package is added.
+func Open(dir string) (*Store, error) {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store directory %q: %w", dir, err)
+	}
+	return &Store{root: root}, nil
+}
+
+// Dir returns the absolute path of the store.
+func (s *Store) Dir() string {
+	return s.root
+}
+
+// Path returns the directory holding the package with the given archive
+// digest, formatted as "sha256:<hex>".
+func (s *Store) Path(digest string) (string, error) {
+	if !digestPattern.MatchString(digest) {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	sum := digest[len("sha256:"):]
+	return filepath.Join(s.root, "sha256", sum[:2], sum), nil
+}
+
+// Has reports whether the package with the given digest is in the store.
+func (s *Store) Has(digest string) bool {
+	dir, err := s.Path(digest)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// DigestError reports an archive whose digest is not the expected one.
+type DigestError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *DigestError) Error() string {
+	return fmt.Sprintf("digest mismatch: expected %s, got %s", e.Expected, e.Actual)
+}
+
+// Add unpacks the package archive read from r into the store and returns
+// its digest. When expected is set, an archive with another digest fails
+// with a *DigestError before anything is unpacked. Adding an archive that
+// is already stored only reads it.
+func (s *Store) Add(r io.Reader, expected string) (string, error) {
+	if err := os.MkdirAll(s.root, 0755); err != nil {
+		return "", fmt.Errorf("failed to create store: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.root, ".archive-*.tgz")
+	if err != nil {
+		return "", fmt.Errorf("failed to add to store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), r); err != nil {
+		return "", fmt.Errorf("failed to read archive: %w", err)
+	}
+	digest := "sha256:" + hex.EncodeToString(hash.Sum(nil))
+	if expected != "" && digest != expected {
+		return "", &DigestError{Expected: expected, Actual: digest}
+	}
+	if s.Has(digest) {
+		return digest, nil
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to add to store: %w", err)
+	}
+	if err := s.unpack(digest, tmp); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// unpack extracts the archive read from r next to its place in the store
+// and renames it into place.
+func (s *Store) unpack(digest string, r io.Reader) error {
+	dir, err := s.Path(digest)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create store: %w", err)
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(dir), ".unpack-*")
+	if err != nil {
+		return fmt.Errorf("failed to add to store: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	files := filepath.Join(staging, "files")
+	if err := archive.Extract(r, files, archive.DefaultLimits); err != nil {
+		return fmt.Errorf("failed to unpack %s: %w", digest, err)
+	}
+	if err := os.Rename(files, dir); err != nil {
+		// Another install may have stored the same package meanwhile.
+		if s.Has(digest) {
+			return nil
+		}
+		return fmt.Errorf("failed to add to store: %w", err)
+	}
+	return nil
+}
+
+// Target returns the path of the slash-separated rel in root. It fails
+// when that path leads out of root or through a symbolic link below root,
+// so a crafted name can neither write nor remove anything outside root.
+func Target(root, rel string) (string, error) {
+	root = filepath.Clean(root)
+	target := filepath.Join(root, filepath.FromSlash(rel))
+	r, err := filepath.Rel(root, target)
+	if err != nil || r == "." || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%q leads outside %s", rel, root)
+	}
+	dir := root
+	parts := strings.Split(r, string(filepath.Separator))
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("%q leads through the symbolic link %s", rel, dir)
+		}
+	}
+	return target, nil
+}
+
+// Link makes rel in root, a slash-separated path checked with Target, a
+// copy of the stored package with the given digest, replacing whatever it
+// held. Files are hard links into the store where the file system allows
+// it and copies otherwise.
+func (s *Store) Link(digest, root, rel string) error {
+	src, err := s.Path(digest)
+	if err != nil {
+		return err
+	}
+	target, err := Target(root, rel)
+	if err != nil {
+		return err
+	}
+	if !s.Has(digest) {
+		return fmt.Errorf("package %s is not in the store", digest)
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", target, err)
+	}
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(target, rel)
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0755)
+		}
+		if err := os.Link(path, dst); err == nil {
+			return nil
+		}
+		return copyFile(path, dst)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to link %s: %w", target, err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"agenthub/internal/archive"
+)
+
+// packArchive returns the archive of a package with the given files.
+func packArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	dir := t.TempDir()
+	var names []string
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		names = append(names, name)
+	}
+	var buf bytes.Buffer
+	_, err := archive.Pack(&buf, dir, names)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestAdd(t *testing.T) {
+	st, err := Open(t.TempDir())
+	require.NoError(t, err)
+	data := packArchive(t, map[string]string{"agentpkg.yaml": "name: a\n", "prompts/system.md": "Hi\n"})
+	sum := sha256.Sum256(data)
+	want := "sha256:" + hex.EncodeToString(sum[:])
+
+	assert.False(t, st.Has(want))
+	digest, err := st.Add(bytes.NewReader(data), "")
+	require.NoError(t, err)
+	assert.Equal(t, want, digest)
+	assert.True(t, st.Has(digest))
+
+	dir, err := st.Path(digest)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(st.Dir(), "sha256", want[7:9], want[7:]), dir)
+	assert.FileExists(t, filepath.Join(dir, "prompts", "system.md"))
+
+	// Adding the same archive again is a no-op.
+	digest, err = st.Add(bytes.NewReader(data), "")
+	require.NoError(t, err)
+	assert.Equal(t, want, digest)
+
+	// Nothing but packages is left behind.
+	entries, err := os.ReadDir(st.Dir())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "sha256", entries[0].Name())
+}
+
+func TestAddInvalidArchive(t *testing.T) {
+	st, err := Open(t.TempDir())
+	require.NoError(t, err)
+	_, err = st.Add(bytes.NewReader([]byte("not an archive")), "")
+	assert.ErrorContains(t, err, "invalid archive")
+
+	matches, err := filepath.Glob(filepath.Join(st.Dir(), "sha256", "*", "*"))
+	require.NoError(t, err)
+	assert.Empty(t, matches, "a failed unpack stores nothing")
+}
+
+func TestLink(t *testing.T) {
+	st, err := Open(t.TempDir())
+	require.NoError(t, err)
+	digest, err := st.Add(bytes.NewReader(packArchive(t, map[string]string{"agentpkg.yaml": "name: a\n"})), "")
+	require.NoError(t, err)
+
+	modules := filepath.Join(t.TempDir(), "agent_modules")
+	target := filepath.Join(modules, "a")
+	require.NoError(t, os.MkdirAll(target, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(target, "stale.txt"), nil, 0644))
+	require.NoError(t, st.Link(digest, modules, "a"))
+
+	assert.NoFileExists(t, filepath.Join(target, "stale.txt"))
+	linked, err := os.Stat(filepath.Join(target, "agentpkg.yaml"))
+	require.NoError(t, err)
+	dir, err := st.Path(digest)
+	require.NoError(t, err)
+	stored, err := os.Stat(filepath.Join(dir, "agentpkg.yaml"))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(linked, stored), "files are hard links into the store")
+
+	err = st.Link("sha256:"+hex.EncodeToString(make([]byte, 32)), modules, "a")
+	assert.ErrorContains(t, err, "not in the store")
+	_, err = st.Path("md5:abc")
+	assert.ErrorContains(t, err, "invalid digest")
+}
+
+func TestTarget(t *testing.T) {
+	root := t.TempDir()
+	modules := filepath.Join(root, "agent_modules")
+	require.NoError(t, os.MkdirAll(modules, 0755))
+
+	target, err := Target(modules, "@team/tool")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(modules, "@team", "tool"), target)
+
+	for _, rel := range []string{"..", "../victim", "a/../../victim", ".", ""} {
+		_, err := Target(modules, rel)
+		assert.ErrorContains(t, err, "leads outside", rel)
+	}
+
+	// A scope directory planted as a link would redirect the install.
+	outside := filepath.Join(root, "outside")
+	require.NoError(t, os.MkdirAll(outside, 0755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(modules, "@evil")))
+	_, err = Target(modules, "@evil/tool")
+	assert.ErrorContains(t, err, "symbolic link")
+}
+
+func TestOpenDefault(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	st, err := Open("")
+	require.NoError(t, err)
+	want, err := DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, want, st.Dir())
+	assert.Equal(t, "store", filepath.Base(st.Dir()))
+}
+
+func TestAddVerifiesDigest(t *testing.T) {
+	st, err := Open(t.TempDir())
+	require.NoError(t, err)
+	data := packArchive(t, map[string]string{"agentpkg.yaml": "name: a\n"})
+	sum := sha256.Sum256(data)
+	actual := "sha256:" + hex.EncodeToString(sum[:])
+	expected := "sha256:" + hex.EncodeToString(make([]byte, 32))
+
+	_, err = st.Add(bytes.NewReader(data), expected)
+	var digestErr *DigestError
+	require.ErrorAs(t, err, &digestErr)
+	assert.Equal(t, expected, digestErr.Expected)
+	assert.Equal(t, actual, digestErr.Actual)
+	assert.False(t, st.Has(actual), "a mismatched archive is never unpacked")
+	assert.False(t, st.Has(expected))
+
+	digest, err := st.Add(bytes.NewReader(data), actual)
+	require.NoError(t, err)
+	assert.Equal(t, actual, digest)
+}
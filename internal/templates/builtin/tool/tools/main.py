@@ -0,0 +1,12 @@
+"""Entry point of the {{agenthub.name}} tool."""
+
+import json
+import sys
+
+
+def run(args):
+    return {"text": args["text"]}
+
+
+if __name__ == "__main__":
+    print(json.dumps(run(json.load(sys.stdin))))
@@ -0,0 +1,149 @@
+// Package templates scaffolds new projects from built-in templates or from
+// templates published to a registry.
+package templates
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"agenthub/internal/archive"
+)
+
+//go:embed all:builtin
+var builtinFS embed.FS
+
+// Vars are the values substituted into template files. A file refers to
+// them as {{agenthub.name}} and {{agenthub.author}}; the agenthub prefix
+// keeps them apart from the {{variable}} placeholders of prompt templates,
+// which are left untouched.
+type Vars struct {
+	Name   string
+	Author string
+}
+
+func (v Vars) replacer() *strings.Replacer {
+	return strings.NewReplacer(
+		"{{agenthub.name}}", v.Name,
+		"{{agenthub.author}}", v.Author,
+	)
+}
+
+// Template is a set of files written into a new project.
+type Template struct {
+	Name        string
+	Description string
+	// Files maps slash-separated paths to file contents
+	Files map[string][]byte
+}
+
+// builtin describes the templates embedded in the binary, in the order
+// they are listed.
+var builtin = []struct {
+	name, description string
+}{
+	{"agent", "An agent with a system prompt and example conversations"},
+	{"tool", "A tool with an input/output schema and a Python entrypoint"},
+	{"chain", "A multi-step chain of prompts"},
+	{"prompt-library", "A library of reusable prompt templates"},
+	{"dataset", "A JSON Lines dataset with record checks"},
+}
+
+// Names returns the names of the built-in templates.
+func Names() []string {
+	var names []string
+	for _, b := range builtin {
+		names = append(names, b.name)
+	}
+	return names
+}
+
+// Lookup returns the built-in template called name.
+func Lookup(name string) (*Template, error) {
+	for _, b := range builtin {
+		if b.name != name {
+			continue
+		}
+		root := path.Join("builtin", name)
+		files := make(map[string][]byte)
+		err := fs.WalkDir(builtinFS, root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := builtinFS.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			files[strings.TrimPrefix(p, root+"/")] = data
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load template %s: %w", name, err)
+		}
+		return &Template{Name: name, Description: b.description, Files: files}, nil
+	}
+	return nil, fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(Names(), ", "))
+}
+
+// PackageDir is the directory of a registry package that holds its
+// template files. The rest of the package, including its own manifest, is
+// not copied.
+const PackageDir = "template"
+
+// FromArchive returns the template published as the package archive read
+// from r.
+func FromArchive(name string, r io.Reader) (*Template, error) {
+	contents, err := archive.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	for p, data := range contents {
+		if rel, ok := strings.CutPrefix(p, PackageDir+"/"); ok {
+			files[rel] = data
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("package %s is not a template: it has no %s/ directory", name, PackageDir)
+	}
+	return &Template{Name: name, Files: files}, nil
+}
+
+// Render writes the template's files into dir with vars substituted,
+// returning the paths written in sorted order. Existing files are only
+// replaced when overwrite is set.
+func (t *Template) Render(dir string, vars Vars, overwrite bool) ([]string, error) {
+	paths := make([]string, 0, len(t.Files))
+	for p := range t.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	replacer := vars.replacer()
+	for _, p := range paths {
+		target := filepath.Join(dir, filepath.FromSlash(p))
+		if _, err := os.Stat(target); err == nil && !overwrite {
+			return nil, fmt.Errorf("template file %s already exists", p)
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", p, err)
+		}
+		mode := os.FileMode(0644)
+		if strings.HasPrefix(string(t.Files[p]), "#!") {
+			mode = 0755
+		}
+		if err := os.WriteFile(target, []byte(replacer.Replace(string(t.Files[p]))), mode); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", p, err)
+		}
+	}
+	return paths, nil
+}
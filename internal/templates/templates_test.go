@@ -0,0 +1,107 @@
+package templates
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"agenthub/internal/archive"
+	"agenthub/pkg"
+)
+
+func TestBuiltinTemplatesRenderValidPackages(t *testing.T) {
+	kinds := map[string]pkg.Kind{
+		"agent":          pkg.KindAgent,
+		"tool":           pkg.KindTool,
+		"chain":          pkg.KindChain,
+		"prompt-library": pkg.KindPrompt,
+		"dataset":        pkg.KindDataset,
+	}
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := Lookup(name)
+			require.NoError(t, err)
+			assert.NotEmpty(t, tmpl.Description)
+
+			dir := t.TempDir()
+			files, err := tmpl.Render(dir, Vars{Name: "@team/my-" + name, Author: "Ada Lovelace"}, false)
+			require.NoError(t, err)
+			assert.Contains(t, files, "agentpkg.yaml")
+			assert.Contains(t, files, "README.md")
+
+			agentPkg, err := pkg.LoadAgentPkg(filepath.Join(dir, "agentpkg.yaml"))
+			require.NoError(t, err)
+			require.NoError(t, pkg.ValidateAgentPkg(agentPkg))
+			assert.Equal(t, "@team/my-"+name, agentPkg.Name)
+			assert.Equal(t, "Ada Lovelace", agentPkg.Author)
+			assert.Equal(t, kinds[name], agentPkg.Kind)
+
+			readme, err := os.ReadFile(filepath.Join(dir, "README.md"))
+			require.NoError(t, err)
+			assert.NotContains(t, string(readme), "{{agenthub.")
+
+			packed, err := archive.Files(dir)
+			require.NoError(t, err)
+			assert.Equal(t, files, packed, "every template file is packed")
+		})
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	_, err := Lookup("spreadsheet")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "agent, tool, chain, prompt-library, dataset")
+}
+
+func TestRenderKeepsPromptPlaceholders(t *testing.T) {
+	tmpl := &Template{Files: map[string][]byte{
+		"prompts/greet.md": []byte("Hi {{user}}, I am {{agenthub.name}}.\n"),
+	}}
+	dir := t.TempDir()
+	_, err := tmpl.Render(dir, Vars{Name: "greeter"}, false)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "prompts", "greet.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "Hi {{user}}, I am greeter.\n", string(data))
+
+	_, err = tmpl.Render(dir, Vars{Name: "greeter"}, false)
+	assert.ErrorContains(t, err, "already exists")
+	_, err = tmpl.Render(dir, Vars{Name: "other"}, true)
+	assert.NoError(t, err)
+}
+
+func TestFromArchive(t *testing.T) {
+	src := t.TempDir()
+	for name, content := range map[string]string{
+		"agentpkg.yaml":              "name: my-template\nversion: 1.0.0\n",
+		"template/agentpkg.yaml":     "name: \"{{agenthub.name}}\"\nversion: 0.1.0\n",
+		"template/prompts/system.md": "You are {{agenthub.name}}.\n",
+	} {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	files, err := archive.Files(src)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	_, err = archive.Pack(&buf, src, files)
+	require.NoError(t, err)
+
+	tmpl, err := FromArchive("my-template@1.0.0", bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "my-template@1.0.0", tmpl.Name)
+	assert.Len(t, tmpl.Files, 2)
+	assert.Contains(t, tmpl.Files, "agentpkg.yaml")
+	assert.Contains(t, tmpl.Files, "prompts/system.md")
+
+	buf.Reset()
+	_, err = archive.Pack(&buf, src, []string{"agentpkg.yaml"})
+	require.NoError(t, err)
+	_, err = FromArchive("plain", &buf)
+	assert.ErrorContains(t, err, "not a template")
+}
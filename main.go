@@ -1,14 +1,23 @@
 package main
 
 import (
+    "errors"
     "fmt"
     "os"
     
     "agenthub/cmd"
+    "agenthub/internal/commands"
 )
 
 func main() {
     if err := cmd.Execute(); err != nil {
+        var exitErr *commands.ExitError
+        if errors.As(err, &exitErr) {
+            if exitErr.Err != nil {
+                fmt.Fprintf(os.Stderr, "Error: %v\n", exitErr.Err)
+            }
+            os.Exit(exitErr.Code)
+        }
         fmt.Fprintf(os.Stderr, "Error: %v\n", err)
         os.Exit(1)
     }
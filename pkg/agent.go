@@ -1,71 +1,369 @@
 package pkg
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
+// ManifestNames lists the file names recognised as an agent package
+// manifest, in order of preference.
+var ManifestNames = []string{"agentpkg.yaml", "agentpkg.yml", "agentpkg.json"}
+
 // AgentPkg represents an agent package configuration
 type AgentPkg struct {
-	Name        string            `yaml:"name"`
-	Version     string            `yaml:"version"`
-	Description string            `yaml:"description"`
-	Author      string            `yaml:"author"`
-	Dependencies map[string]string `yaml:"dependencies"`
+	Name         string            `yaml:"name" json:"name"`
+	Version      string            `yaml:"version" json:"version"`
+	Description  string            `yaml:"description,omitempty" json:"description,omitempty"`
+	Author       string            `yaml:"author,omitempty" json:"author,omitempty"`
+	License      string            `yaml:"license,omitempty" json:"license,omitempty"`
+	Registry     string            `yaml:"registry,omitempty" json:"registry,omitempty"`
+	Dependencies map[string]string `yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
+	// DevDependencies are only installed for work on the package itself,
+	// never for packages that depend on it.
+	DevDependencies map[string]string `yaml:"devDependencies,omitempty" json:"devDependencies,omitempty"`
+
+	// Kind selects which of the kind-specific sections below the package
+	// has. It is empty for a plain package with no section.
+	Kind    Kind         `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Agent   *AgentSpec   `yaml:"agent,omitempty" json:"agent,omitempty"`
+	Tool    *ToolSpec    `yaml:"tool,omitempty" json:"tool,omitempty"`
+	Chain   *ChainSpec   `yaml:"chain,omitempty" json:"chain,omitempty"`
+	Prompt  *PromptSpec  `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+	Dataset *DatasetSpec `yaml:"dataset,omitempty" json:"dataset,omitempty"`
+}
+
+// AllDependencies returns the dependencies and development dependencies of
+// the package together, as installed for work on the package itself.
+func (a *AgentPkg) AllDependencies() map[string]string {
+	if len(a.DevDependencies) == 0 {
+		return a.Dependencies
+	}
+	all := make(map[string]string, len(a.Dependencies)+len(a.DevDependencies))
+	for name, constraint := range a.DevDependencies {
+		all[name] = constraint
+	}
+	for name, constraint := range a.Dependencies {
+		all[name] = constraint
+	}
+	return all
+}
+
+// ManifestError describes a problem decoding a manifest file. Line and
+// Column are 1-based and zero when the position is unknown.
+type ManifestError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
 }
 
-// LoadAgentPkg loads an agent package from a YAML file
+func (e *ManifestError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	default:
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	}
+}
+
+// FindAgentPkg returns the path of the manifest in dir, trying each of
+// ManifestNames in turn.
+func FindAgentPkg(dir string) (string, error) {
+	for _, name := range ManifestNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+	}
+	return "", fmt.Errorf("no agentpkg.yaml found in %s: %w", dir, os.ErrNotExist)
+}
+
+// LoadAgentPkg loads an agent package from a YAML or JSON file
 func LoadAgentPkg(filename string) (*AgentPkg, error) {
-	// Stub implementation - in reality this would parse a YAML file
 	if filename == "" {
 		return nil, fmt.Errorf("filename cannot be empty")
 	}
-	
-	// Return a sample agent package for testing
-	return &AgentPkg{
-		Name:        "sample-agent",
-		Version:     "1.0.0",
-		Description: "A sample agent package",
-		Author:      "AgentHub",
-		Dependencies: map[string]string{
-			"some-tool": "^1.0.0",
-		},
-	}, nil
-}
-
-// ValidateAgentPkg validates an agent package configuration
-func ValidateAgentPkg(agentPkg *AgentPkg) error {
-	if agentPkg == nil {
-		return fmt.Errorf("agentPkg cannot be nil")
-	}
-	
-	if agentPkg.Name == "" {
-		return fmt.Errorf("agent package name is required")
-	}
-	
-	if agentPkg.Version == "" {
-		return fmt.Errorf("agent package version is required")
-	}
-	
-	// Additional validation logic would go here
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return ParseAgentPkg(filename, data)
+}
+
+// SaveAgentPkg writes an agent package to a YAML or JSON file, choosing the
+// format from the extension of filename
+func SaveAgentPkg(filename string, agentPkg *AgentPkg) error {
+	var data []byte
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(agentPkg); err != nil {
+			return fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		data = buf.Bytes()
+	case ".json":
+		encoded, err := json.MarshalIndent(agentPkg, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		data = append(encoded, '\n')
+	default:
+		return fmt.Errorf("unsupported manifest format %q: use .yaml, .yml or .json", filepath.Ext(filename))
+	}
+
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// ParseAgentPkg decodes manifest data. The format is chosen from the
+// extension of filename, which is also used in error messages. Unknown
+// keys are rejected.
+func ParseAgentPkg(filename string, data []byte) (*AgentPkg, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return decodeYAML(filename, data)
+	case ".json":
+		return decodeJSON(filename, data)
+	default:
+		return nil, fmt.Errorf("unsupported manifest format %q: expected .yaml, .yml or .json", filepath.Ext(filename))
+	}
+}
+
+var yamlLineRe = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+var yamlUnknownFieldRe = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+
+func decodeYAML(filename string, data []byte) (*AgentPkg, error) {
+	// The node tree is only used to recover columns, which yaml.v3 leaves
+	// out of its decode errors.
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, yamlError(filename, nil, err.Error())
+	}
+	if root.Kind == 0 {
+		return nil, &ManifestError{File: filename, Msg: "manifest is empty"}
+	}
+
+	var agentPkg AgentPkg
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&agentPkg); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			errs := make([]error, 0, len(typeErr.Errors))
+			for _, msg := range typeErr.Errors {
+				errs = append(errs, yamlError(filename, &root, msg))
+			}
+			return nil, errors.Join(errs...)
+		}
+		return nil, yamlError(filename, &root, err.Error())
+	}
+
+	var extra yaml.Node
+	if err := dec.Decode(&extra); err != io.EOF {
+		line := 0
+		if err == nil {
+			line = extra.Line
+		}
+		return nil, &ManifestError{File: filename, Line: line, Msg: "manifest must contain a single YAML document"}
+	}
+
+	return &agentPkg, nil
+}
+
+// yamlError converts a yaml.v3 error message into a ManifestError, looking
+// up the column of the offending node in root when it is available.
+func yamlError(filename string, root *yaml.Node, msg string) *ManifestError {
+	m := yamlLineRe.FindStringSubmatch(msg)
+	if m == nil {
+		return &ManifestError{File: filename, Msg: strings.TrimPrefix(msg, "yaml: ")}
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	msg = m[2]
+	key := ""
+	if u := yamlUnknownFieldRe.FindStringSubmatch(msg); u != nil {
+		key = u[1]
+		msg = fmt.Sprintf("unknown field %q", key)
+	}
+
+	return &ManifestError{File: filename, Line: line, Column: columnAt(root, line, key), Msg: msg}
+}
+
+// columnAt returns the column of the node on line that an error most likely
+// refers to: the mapping key named key when one is given, otherwise the
+// value of the entry on that line.
+func columnAt(node *yaml.Node, line int, key string) int {
+	if node == nil {
+		return 0
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if k.Line != line {
+				continue
+			}
+			if key != "" {
+				if k.Value == key {
+					return k.Column
+				}
+				continue
+			}
+			if v.Line == line {
+				return v.Column
+			}
+			return k.Column
+		}
+	} else if node.Kind == yaml.ScalarNode && node.Line == line {
+		return node.Column
+	}
+	for _, child := range node.Content {
+		if col := columnAt(child, line, key); col > 0 {
+			return col
+		}
+	}
+	return 0
+}
+
+var jsonUnknownFieldRe = regexp.MustCompile(`^json: unknown field "(.*)"$`)
+
+func decodeJSON(filename string, data []byte) (*AgentPkg, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, &ManifestError{File: filename, Msg: "manifest is empty"}
+	}
+
+	var agentPkg AgentPkg
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&agentPkg); err != nil {
+		return nil, jsonError(filename, data, err)
+	}
+	if dec.More() {
+		line, col := lineCol(data, dec.InputOffset())
+		return nil, &ManifestError{File: filename, Line: line, Column: col, Msg: "unexpected data after top-level object"}
+	}
+
+	return &agentPkg, nil
+}
+
+func jsonError(filename string, data []byte, err error) *ManifestError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := lineCol(data, syntaxErr.Offset-1)
+		return &ManifestError{File: filename, Line: line, Column: col, Msg: strings.TrimPrefix(err.Error(), "json: ")}
+	case errors.As(err, &typeErr):
+		line, col := lineCol(data, typeErr.Offset-1)
+		msg := fmt.Sprintf("cannot decode %s into %s", typeErr.Value, typeErr.Type)
+		if typeErr.Field != "" {
+			msg = fmt.Sprintf("field %q: %s", typeErr.Field, msg)
+		}
+		return &ManifestError{File: filename, Line: line, Column: col, Msg: msg}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		line, col := lineCol(data, int64(len(data)))
+		return &ManifestError{File: filename, Line: line, Column: col, Msg: "unexpected end of JSON input"}
+	}
+
+	if m := jsonUnknownFieldRe.FindStringSubmatch(err.Error()); m != nil {
+		// encoding/json does not report where the key was, so find it.
+		keyRe := regexp.MustCompile(regexp.QuoteMeta(strconv.Quote(m[1])) + `\s*:`)
+		if loc := keyRe.FindIndex(data); loc != nil {
+			line, col := lineCol(data, int64(loc[0]))
+			return &ManifestError{File: filename, Line: line, Column: col, Msg: fmt.Sprintf("unknown field %q", m[1])}
+		}
+		return &ManifestError{File: filename, Msg: fmt.Sprintf("unknown field %q", m[1])}
+	}
+
+	return &ManifestError{File: filename, Msg: strings.TrimPrefix(err.Error(), "json: ")}
+}
+
+// lineCol converts a byte offset into a 1-based line and column.
+func lineCol(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+var namePattern = regexp.MustCompile(`^(@[a-z0-9][a-z0-9._-]*/)?[a-z0-9][a-z0-9._-]*$`)
+
+// ValidateName checks that name is a valid package name: lowercase letters,
+// digits, ".", "_" and "-", starting with a letter or digit, optionally
+// scoped as @scope/name, and never containing "..".
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid package name %q", name)
+	}
 	return nil
 }
 
-// ResolveVersion resolves a version requirement against available versions
+// ResolveVersion resolves a version requirement against available versions,
+// returning the highest available version that satisfies it
 func ResolveVersion(required string, available []string) (string, error) {
 	if required == "" {
 		return "", fmt.Errorf("required version cannot be empty")
 	}
-	
+
 	if len(available) == 0 {
 		return "", fmt.Errorf("no available versions")
 	}
-	
-	// Simplified version resolution - in reality this would handle semver
-	for _, version := range available {
-		if version != "" {
-			return version, nil
+
+	constraint, err := ParseConstraint(required)
+	if err != nil {
+		return "", err
+	}
+
+	// Entries that are not valid versions can never match, so they are
+	// skipped rather than failing the whole resolution.
+	var versions []*Version
+	originals := make(map[*Version]string)
+	for _, s := range available {
+		v, err := ParseVersion(s)
+		if err != nil {
+			continue
 		}
+		versions = append(versions, v)
+		originals[v] = s
 	}
-	
-	return "", fmt.Errorf("no suitable version found")
-} 
\ No newline at end of file
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].compareTotal(versions[j]) < 0
+	})
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if constraint.Check(versions[i]) {
+			return originals[versions[i]], nil
+		}
+	}
+
+	return "", &VersionError{Constraint: required, Nearest: nearestVersions(constraint, versions)}
+}
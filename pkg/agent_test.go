@@ -1,8 +1,13 @@
 package pkg
 
 import (
+    "errors"
+    "os"
+    "path/filepath"
     "testing"
+
     "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
 )
 
 // Tests for AgentPkg validation
@@ -32,9 +37,96 @@ func TestValidateAgentPkgMissingName(t *testing.T) {
 
 // Tests for AgentPkg loading
 func TestLoadAgentPkg(t *testing.T) {
-    agentPkg, err := LoadAgentPkg("valid_agent.yaml")
+    agentPkg, err := LoadAgentPkg(filepath.Join("testdata", "valid_agent.yaml"))
     assert.NoError(t, err)
     assert.NotEmpty(t, agentPkg)
+    assert.Equal(t, "valid-agent", agentPkg.Name)
+}
+
+func TestLoadAgentPkgFormats(t *testing.T) {
+    for _, name := range []string{"sample_agent.yaml", "sample_agent.yml", "sample_agent.json"} {
+        t.Run(name, func(t *testing.T) {
+            agentPkg, err := LoadAgentPkg(filepath.Join("testdata", name))
+            require.NoError(t, err)
+            assert.Equal(t, "sample-agent", agentPkg.Name)
+            assert.Equal(t, "1.0.0", agentPkg.Version)
+            assert.Equal(t, map[string]string{"some-tool": "^1.0.0"}, agentPkg.Dependencies)
+        })
+    }
+}
+
+func TestLoadAgentPkgMissingFile(t *testing.T) {
+    _, err := LoadAgentPkg(filepath.Join("testdata", "missing.yaml"))
+    assert.Error(t, err)
+    assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestLoadAgentPkgUnknownField(t *testing.T) {
+    testCases := []struct {
+        file   string
+        line   int
+        column int
+    }{
+        {"unknown_field.yaml", 3, 1},
+        {"unknown_field.json", 4, 3},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.file, func(t *testing.T) {
+            _, err := LoadAgentPkg(filepath.Join("testdata", tc.file))
+            require.Error(t, err)
+
+            var manifestErr *ManifestError
+            require.True(t, errors.As(err, &manifestErr))
+            assert.Equal(t, tc.line, manifestErr.Line)
+            assert.Equal(t, tc.column, manifestErr.Column)
+            assert.Contains(t, err.Error(), `unknown field "dependencis"`)
+        })
+    }
+}
+
+func TestParseAgentPkgErrors(t *testing.T) {
+    testCases := []struct {
+        name     string
+        filename string
+        data     string
+        contains string
+    }{
+        {"empty yaml", "agentpkg.yaml", "", "manifest is empty"},
+        {"empty json", "agentpkg.json", "  ", "manifest is empty"},
+        {"yaml syntax", "agentpkg.yaml", "name: a\n  version: [", "agentpkg.yaml:2"},
+        {"yaml wrong type", "agentpkg.yaml", "name: a\ndependencies: [x]\n", "agentpkg.yaml:2:15"},
+        {"yaml multiple documents", "agentpkg.yaml", "name: a\n---\nname: b\n", "single YAML document"},
+        {"json syntax", "agentpkg.json", "{\n  \"name\": \"a\",\n}", "agentpkg.json:3:1"},
+        {"json wrong type", "agentpkg.json", "{\"name\": 1}", `field "name"`},
+        {"json trailing data", "agentpkg.json", "{} {}", "unexpected data"},
+        {"unsupported format", "agentpkg.toml", "name = 'a'", "unsupported manifest format"},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            agentPkg, err := ParseAgentPkg(tc.filename, []byte(tc.data))
+            assert.Nil(t, agentPkg)
+            require.Error(t, err)
+            assert.Contains(t, err.Error(), tc.contains)
+        })
+    }
+}
+
+func TestFindAgentPkg(t *testing.T) {
+    dir := t.TempDir()
+    _, err := FindAgentPkg(dir)
+    assert.True(t, errors.Is(err, os.ErrNotExist))
+
+    require.NoError(t, os.WriteFile(filepath.Join(dir, "agentpkg.json"), []byte("{}"), 0644))
+    path, err := FindAgentPkg(dir)
+    require.NoError(t, err)
+    assert.Equal(t, filepath.Join(dir, "agentpkg.json"), path)
+
+    require.NoError(t, os.WriteFile(filepath.Join(dir, "agentpkg.yaml"), []byte("name: a\n"), 0644))
+    path, err = FindAgentPkg(dir)
+    require.NoError(t, err)
+    assert.Equal(t, filepath.Join(dir, "agentpkg.yaml"), path)
 }
 
 func TestLoadAgentPkgEmptyFilename(t *testing.T) {
@@ -47,7 +139,7 @@ func TestLoadAgentPkgEmptyFilename(t *testing.T) {
 // Tests for YAML specs validation (moved from validation_test.go)
 func TestValidateYAMLSpecs(t *testing.T) {
     // Load and validate a sample agent package
-    agentPkg, err := LoadAgentPkg("sample_agent.yaml")
+    agentPkg, err := LoadAgentPkg(filepath.Join("testdata", "sample_agent.yaml"))
     assert.NoError(t, err)
     assert.NotNil(t, agentPkg)
     err = ValidateAgentPkg(agentPkg)
@@ -60,7 +152,25 @@ func TestResolveVersion(t *testing.T) {
     available := []string{"1.0.0", "1.0.1"}
     version, err := ResolveVersion(required, available)
     assert.NoError(t, err)
-    assert.Equal(t, "1.0.0", version)
+    assert.Equal(t, "1.0.1", version)
+}
+
+func TestResolveVersionNoMatch(t *testing.T) {
+    version, err := ResolveVersion("^1.0.0", []string{"0.2.0", "0.3.0", "2.0.0", "3.0.0", "4.0.0"})
+    assert.Empty(t, version)
+    require.Error(t, err)
+
+    var versionErr *VersionError
+    require.True(t, errors.As(err, &versionErr))
+    assert.Equal(t, "^1.0.0", versionErr.Constraint)
+    assert.Equal(t, []string{"0.2.0", "0.3.0", "2.0.0", "3.0.0"}, versionErr.Nearest)
+    assert.Contains(t, err.Error(), `"^1.0.0"`)
+}
+
+func TestResolveVersionInvalidConstraint(t *testing.T) {
+    _, err := ResolveVersion("^1.0.0.0", []string{"1.0.0"})
+    assert.Error(t, err)
+    assert.Contains(t, err.Error(), "invalid version constraint")
 }
 
 func TestResolveVersionEmptyRequired(t *testing.T) {
@@ -85,5 +195,26 @@ func TestValidatePackageDependencies(t *testing.T) {
     available := []string{"1.0.0", "1.0.1"}
     version, err := ResolveVersion(required, available)
     assert.NoError(t, err)
-    assert.Equal(t, "1.0.0", version)
-} 
\ No newline at end of file
+    assert.Equal(t, "1.0.1", version)
+} 
+func TestSaveAgentPkgRoundTrip(t *testing.T) {
+    original := &AgentPkg{
+        Name:         "support-bot",
+        Version:      "0.1.0",
+        Author:       "Ada",
+        Registry:     "https://registry.example.com",
+        Dependencies: map[string]string{"web-search": "^1.0.0"},
+    }
+
+    for _, name := range []string{"agentpkg.yaml", "agentpkg.json"} {
+        path := filepath.Join(t.TempDir(), name)
+        require.NoError(t, SaveAgentPkg(path, original))
+
+        loaded, err := LoadAgentPkg(path)
+        require.NoError(t, err)
+        assert.Equal(t, original, loaded)
+    }
+
+    err := SaveAgentPkg(filepath.Join(t.TempDir(), "agentpkg.toml"), original)
+    assert.Error(t, err)
+}
@@ -0,0 +1,155 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// placeholderPattern matches the {{variable}} placeholders of prompt
+// templates.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// CheckContents returns the problems with the files the manifest of the
+// package in dir refers to. files lists the slash-separated paths that
+// are packed into the package's archive; a referenced file must be one of
+// them.
+func CheckContents(dir string, files []string, agentPkg *AgentPkg) []Problem {
+	c := &checker{}
+	if agentPkg == nil {
+		return nil
+	}
+	packed := func(p []string, name string) bool {
+		return checkPackedFile(c, dir, files, p, name)
+	}
+
+	switch {
+	case agentPkg.Agent != nil:
+		if agentPkg.Agent.Prompt != "" {
+			packed([]string{"agent", "prompt"}, agentPkg.Agent.Prompt)
+		}
+	case agentPkg.Tool != nil:
+		if agentPkg.Tool.Entrypoint != "" {
+			packed([]string{"tool", "entrypoint"}, agentPkg.Tool.Entrypoint)
+		}
+	case agentPkg.Chain != nil:
+		for i, step := range agentPkg.Chain.Steps {
+			if step.Prompt != "" {
+				packed(fieldPath([]string{"chain", "steps"}, i, "prompt"), step.Prompt)
+			}
+		}
+	case agentPkg.Prompt != nil:
+		for i, tmpl := range agentPkg.Prompt.Templates {
+			p := fieldPath([]string{"prompt", "templates"}, i)
+			if tmpl.File != "" && packed(fieldPath(p, "file"), tmpl.File) {
+				checkPlaceholders(c, dir, p, tmpl)
+			}
+		}
+	case agentPkg.Dataset != nil:
+		records, counted := 0, true
+		for i, f := range agentPkg.Dataset.Files {
+			p := fieldPath([]string{"dataset", "files"}, i)
+			if f == "" || !packed(p, f) {
+				counted = false
+				continue
+			}
+			if agentPkg.Dataset.Format != "jsonl" {
+				counted = false
+				continue
+			}
+			n, ok := checkJSONLines(c, dir, p, f)
+			records += n
+			counted = counted && ok
+		}
+		if counted && agentPkg.Dataset.Records > 0 && records != agentPkg.Dataset.Records {
+			c.warnf([]string{"dataset", "records"}, "records is %d but the files hold %d", agentPkg.Dataset.Records, records)
+		}
+	}
+	return c.problems
+}
+
+// checkPackedFile reports whether the file called name, referred to by
+// the field at p, is packed with the package.
+func checkPackedFile(c *checker, dir string, files []string, p []string, name string) bool {
+	clean := path.Clean(filepath.ToSlash(name))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		c.errorf(p, "%s is outside the package", name)
+		return false
+	}
+	if slices.Contains(files, clean) {
+		return true
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(clean))); err == nil {
+		c.errorf(p, "%s is excluded from the package; check .agenthubignore", name)
+	} else {
+		c.errorf(p, "%s does not exist", name)
+	}
+	return false
+}
+
+// checkPlaceholders compares the placeholders in a prompt template file
+// with the variables it declares.
+func checkPlaceholders(c *checker, dir string, p []string, tmpl PromptTemplate) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(tmpl.File)))
+	if err != nil {
+		c.errorf(fieldPath(p, "file"), "failed to read %s: %v", tmpl.File, err)
+		return
+	}
+	used := make(map[string]bool)
+	for _, m := range placeholderPattern.FindAllStringSubmatch(string(data), -1) {
+		name := m[1]
+		if !used[name] && !slices.Contains(tmpl.Variables, name) {
+			c.warnf(fieldPath(p, "variables"), "%s uses {{%s}}, which is not declared", tmpl.File, name)
+		}
+		used[name] = true
+	}
+	for j, v := range tmpl.Variables {
+		if !used[v] {
+			c.warnf(fieldPath(p, "variables", j), "variable %q is not used in %s", v, tmpl.File)
+		}
+	}
+}
+
+// checkJSONLines checks that every line of a JSON Lines file is a JSON
+// value, returning the number of records and whether all of them parsed.
+func checkJSONLines(c *checker, dir string, p []string, name string) (int, bool) {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		c.errorf(p, "failed to read %s: %v", name, err)
+		return 0, false
+	}
+	defer f.Close()
+
+	records, ok := 0, true
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if !json.Valid([]byte(text)) {
+			if ok {
+				c.errorf(p, "%s:%d is not valid JSON", name, line)
+			}
+			ok = false
+			continue
+		}
+		records++
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("a line is longer than 16 MiB")
+		}
+		c.errorf(p, "failed to read %s: %v", name, err)
+		return records, false
+	}
+	return records, ok
+}
@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckContents(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "prompts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prompts", "a.md"), []byte("Hello {{name}}, {{ topic }}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prompts", "ignored.md"), []byte("x"), 0644))
+	files := []string{"agentpkg.yaml", "prompts/a.md"}
+
+	problems := CheckContents(dir, files, &AgentPkg{
+		Kind: KindPrompt,
+		Prompt: &PromptSpec{Templates: []PromptTemplate{
+			{Name: "a", File: "prompts/a.md", Variables: []string{"name", "unused"}},
+			{Name: "b", File: "prompts/ignored.md"},
+			{Name: "c", File: "prompts/missing.md"},
+			{Name: "d", File: "../outside.md"},
+		}},
+	})
+
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{
+		"warning: prompt.templates[0].variables: prompts/a.md uses {{topic}}, which is not declared",
+		`warning: prompt.templates[0].variables[1]: variable "unused" is not used in prompts/a.md`,
+		"error: prompt.templates[1].file: prompts/ignored.md is excluded from the package; check .agenthubignore",
+		"error: prompt.templates[2].file: prompts/missing.md does not exist",
+		"error: prompt.templates[3].file: ../outside.md is outside the package",
+	}, got)
+}
+
+func TestCheckContentsDataset(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "good.jsonl"), []byte("{\"a\": 1}\n\n[2]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.jsonl"), []byte("{\"a\": 1}\n{oops\n"), 0644))
+	files := []string{"good.jsonl", "bad.jsonl"}
+
+	problems := CheckContents(dir, files, &AgentPkg{
+		Kind:    KindDataset,
+		Dataset: &DatasetSpec{Format: "jsonl", Files: []string{"good.jsonl"}, Records: 3},
+	})
+	require.Len(t, problems, 1)
+	assert.Equal(t, "warning: dataset.records: records is 3 but the files hold 2", problems[0].String())
+
+	problems = CheckContents(dir, files, &AgentPkg{
+		Kind:    KindDataset,
+		Dataset: &DatasetSpec{Format: "jsonl", Files: []string{"good.jsonl", "bad.jsonl"}, Records: 3},
+	})
+	require.Len(t, problems, 1, "the record count is not checked when a file is invalid")
+	assert.Equal(t, "error: dataset.files[1]: bad.jsonl:2 is not valid JSON", problems[0].String())
+}
@@ -0,0 +1,335 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DependencySections lists the manifest sections that map package names to
+// version ranges.
+var DependencySections = []string{"dependencies", "devDependencies"}
+
+// DependencyEdit sets or removes one entry of a dependency section of a
+// manifest.
+type DependencyEdit struct {
+	// Section is one of DependencySections
+	Section string
+	Name    string
+	// Constraint is the version range to record; empty removes the entry
+	Constraint string
+}
+
+// apply makes the edit to agentPkg
+func (e DependencyEdit) apply(agentPkg *AgentPkg) {
+	deps := &agentPkg.Dependencies
+	if e.Section == "devDependencies" {
+		deps = &agentPkg.DevDependencies
+	}
+	if e.Constraint == "" {
+		delete(*deps, e.Name)
+		if len(*deps) == 0 {
+			*deps = nil
+		}
+		return
+	}
+	if *deps == nil {
+		*deps = make(map[string]string)
+	}
+	(*deps)[e.Name] = e.Constraint
+}
+
+// WithDependencyEdits returns a copy of the manifest with edits applied,
+// leaving it unchanged, so the result can be resolved before the manifest
+// file is edited.
+func (a *AgentPkg) WithDependencyEdits(edits ...DependencyEdit) *AgentPkg {
+	edited := *a
+	edited.Dependencies = maps.Clone(a.Dependencies)
+	edited.DevDependencies = maps.Clone(a.DevDependencies)
+	for _, e := range edits {
+		e.apply(&edited)
+	}
+	return &edited
+}
+
+// EditDependencies applies edits to the manifest file. A YAML manifest is
+// changed only on the lines of the entries edited, so its comments, key
+// order and formatting are kept; a JSON manifest is rewritten.
+func EditDependencies(filename string, edits ...DependencyEdit) error {
+	for _, e := range edits {
+		if e.Section != DependencySections[0] && e.Section != DependencySections[1] {
+			return fmt.Errorf("unknown dependency section %q", e.Section)
+		}
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	agentPkg, err := ParseAgentPkg(filename, data)
+	if err != nil {
+		return err
+	}
+
+	if ext := strings.ToLower(filepath.Ext(filename)); ext != ".yaml" && ext != ".yml" {
+		for _, e := range edits {
+			e.apply(agentPkg)
+		}
+		return SaveAgentPkg(filename, agentPkg)
+	}
+
+	for _, e := range edits {
+		if data, err = editYAMLDependency(data, e); err != nil {
+			return fmt.Errorf("failed to update %s: %w", filepath.Base(filename), err)
+		}
+	}
+	// An edit must never leave a manifest that no longer loads.
+	if _, err := ParseAgentPkg(filename, data); err != nil {
+		return fmt.Errorf("failed to update %s: %w", filepath.Base(filename), err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// editYAMLDependency applies e to the YAML manifest data. Block mappings of
+// one-line entries, the usual form, are edited as text; anything else is
+// edited in the node tree and encoded again, which keeps comments and
+// order but not all whitespace.
+func editYAMLDependency(data []byte, e DependencyEdit) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+	key, section := mappingEntry(root, e.Section)
+
+	newline := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+
+	if section == nil {
+		if e.Constraint == "" {
+			return data, nil
+		}
+		text := string(data)
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += newline
+		}
+		text += e.Section + ":" + newline + "  " + yamlScalar(e.Name) + ": " + yamlScalar(e.Constraint) + newline
+		return []byte(text), nil
+	}
+	if !oneLineEntries(section) {
+		return editYAMLNode(&doc, e)
+	}
+
+	entries := section.Content
+	i := 0
+	for i < len(entries) && entries[i].Value != e.Name {
+		i += 2
+	}
+
+	switch {
+	case e.Constraint == "" && i == len(entries):
+		return data, nil
+
+	case e.Constraint == "":
+		first := commentStart(lines, entries[i].Line)
+		if len(entries) == 2 {
+			// The section would be empty; remove it with its last entry.
+			first = key.Line
+		}
+		lines = append(lines[:first-1], lines[entries[i].Line:]...)
+
+	case i < len(entries):
+		value := entries[i+1]
+		line := []rune(lines[value.Line-1])
+		start := value.Column - 1
+		end := start + scalarLength(line[start:], value)
+		if end < start {
+			return editYAMLNode(&doc, e)
+		}
+		replacement := []rune(formatScalar(e.Constraint, value.Style))
+		lines[value.Line-1] = string(line[:start]) + string(replacement) + string(line[end:])
+
+	default:
+		indent := strings.Repeat(" ", entries[0].Column-1)
+		entry := indent + yamlScalar(e.Name) + ": " + yamlScalar(e.Constraint) + newline
+		// Keep a sorted section sorted; otherwise add the entry last.
+		at := entries[len(entries)-2].Line
+		if keysSorted(entries) {
+			for j := 0; j < len(entries); j += 2 {
+				if entries[j].Value > e.Name {
+					at = commentStart(lines, entries[j].Line) - 1
+					break
+				}
+			}
+		}
+		if !strings.HasSuffix(lines[at-1], "\n") {
+			lines[at-1] += newline
+		}
+		lines = append(lines[:at], append([]string{entry}, lines[at:]...)...)
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// editYAMLNode applies e to the node tree of a manifest and encodes it.
+func editYAMLNode(doc *yaml.Node, e DependencyEdit) ([]byte, error) {
+	root := doc.Content[0]
+	key, section := mappingEntry(root, e.Section)
+	if section == nil {
+		if e.Constraint == "" {
+			return yamlEncode(doc)
+		}
+		key = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: e.Section}
+		section = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		root.Content = append(root.Content, key, section)
+	}
+	if section.Kind != yaml.MappingNode {
+		// An empty section is null.
+		*section = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", LineComment: section.LineComment}
+	}
+
+	i := 0
+	for i < len(section.Content) && section.Content[i].Value != e.Name {
+		i += 2
+	}
+	switch {
+	case e.Constraint == "" && i < len(section.Content):
+		section.Content = append(section.Content[:i], section.Content[i+2:]...)
+		if len(section.Content) == 0 {
+			for j := 0; j < len(root.Content); j += 2 {
+				if root.Content[j] == key {
+					root.Content = append(root.Content[:j], root.Content[j+2:]...)
+					break
+				}
+			}
+		}
+	case e.Constraint == "":
+	case i < len(section.Content):
+		section.Content[i+1].Value = e.Constraint
+		section.Content[i+1].Tag = "!!str"
+	default:
+		section.Content = append(section.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: e.Name},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: e.Constraint})
+	}
+	return yamlEncode(doc)
+}
+
+func yamlEncode(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mappingEntry returns the key and value nodes of key name in mapping
+func mappingEntry(mapping *yaml.Node, name string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// oneLineEntries reports whether section is a non-empty block mapping whose
+// entries each take one line, holding a key and a one-line scalar
+func oneLineEntries(section *yaml.Node) bool {
+	if section.Kind != yaml.MappingNode || section.Style&yaml.FlowStyle != 0 || len(section.Content) == 0 {
+		return false
+	}
+	for i := 0; i < len(section.Content); i += 2 {
+		k, v := section.Content[i], section.Content[i+1]
+		if k.Kind != yaml.ScalarNode || v.Kind != yaml.ScalarNode || v.Line != k.Line ||
+			v.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || strings.Contains(v.Value, "\n") {
+			return false
+		}
+	}
+	return true
+}
+
+func keysSorted(entries []*yaml.Node) bool {
+	keys := make([]string, 0, len(entries)/2)
+	for i := 0; i < len(entries); i += 2 {
+		keys = append(keys, entries[i].Value)
+	}
+	return sort.StringsAreSorted(keys)
+}
+
+// commentStart returns the first line of the comment lines directly above
+// line, which document the entry on it, or line itself when there are none
+func commentStart(lines []string, line int) int {
+	for line > 1 && strings.HasPrefix(strings.TrimSpace(lines[line-2]), "#") {
+		line--
+	}
+	return line
+}
+
+// scalarLength returns the length in runes of the one-line scalar value at
+// the start of text, or -1 when it cannot be found there
+func scalarLength(text []rune, value *yaml.Node) int {
+	switch {
+	case value.Style&yaml.SingleQuotedStyle != 0:
+		for i := 1; i < len(text); i++ {
+			if text[i] == '\'' {
+				if i+1 < len(text) && text[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+	case value.Style&yaml.DoubleQuotedStyle != 0:
+		for i := 1; i < len(text); i++ {
+			switch text[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+	default:
+		if n := len([]rune(value.Value)); strings.HasPrefix(string(text), value.Value) {
+			return n
+		}
+	}
+	return -1
+}
+
+// formatScalar writes s as a YAML scalar in the quoting style of the value
+// it replaces
+func formatScalar(s string, style yaml.Style) string {
+	switch {
+	case style&yaml.SingleQuotedStyle != 0:
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	case style&yaml.DoubleQuotedStyle != 0:
+		return strconv.Quote(s)
+	}
+	return yamlScalar(s)
+}
+
+// yamlScalar writes s as a YAML string scalar, quoted only when it must be
+func yamlScalar(s string) string {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return strconv.Quote(s)
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
@@ -0,0 +1,191 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const commentedManifest = `# My research agent
+name: my-app
+version: 1.0.0
+
+dependencies:
+  # searches the web
+  http-tool: ~1.0.0   # pinned until 1.1 is fixed
+  web-search: '^2.0.0'
+
+kind: agent
+agent:
+  model: {name: gpt-4o}
+`
+
+func TestEditDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		edits    []DependencyEdit
+		want     string
+	}{
+		{
+			name:     "add keeps a sorted section sorted",
+			manifest: commentedManifest,
+			edits:    []DependencyEdit{{Section: "dependencies", Name: "summarizer", Constraint: "^1.2.0"}},
+			want: `# My research agent
+name: my-app
+version: 1.0.0
+
+dependencies:
+  # searches the web
+  http-tool: ~1.0.0   # pinned until 1.1 is fixed
+  summarizer: ^1.2.0
+  web-search: '^2.0.0'
+
+kind: agent
+agent:
+  model: {name: gpt-4o}
+`,
+		},
+		{
+			name:     "add before a commented entry",
+			manifest: commentedManifest,
+			edits:    []DependencyEdit{{Section: "dependencies", Name: "@team/eval", Constraint: "*"}},
+			want: `# My research agent
+name: my-app
+version: 1.0.0
+
+dependencies:
+  '@team/eval': '*'
+  # searches the web
+  http-tool: ~1.0.0   # pinned until 1.1 is fixed
+  web-search: '^2.0.0'
+
+kind: agent
+agent:
+  model: {name: gpt-4o}
+`,
+		},
+		{
+			name:     "add last to an unsorted section",
+			manifest: "name: a\nversion: 1.0.0\ndependencies:\n    zeta: ^1.0.0\n    alpha: ^1.0.0",
+			edits:    []DependencyEdit{{Section: "dependencies", Name: "beta", Constraint: "^2.0.0"}},
+			want:     "name: a\nversion: 1.0.0\ndependencies:\n    zeta: ^1.0.0\n    alpha: ^1.0.0\n    beta: ^2.0.0\n",
+		},
+		{
+			name:     "change keeps quoting and comments",
+			manifest: commentedManifest,
+			edits: []DependencyEdit{
+				{Section: "dependencies", Name: "http-tool", Constraint: "~1.1.0"},
+				{Section: "dependencies", Name: "web-search", Constraint: "^3.0.0"},
+			},
+			want: `# My research agent
+name: my-app
+version: 1.0.0
+
+dependencies:
+  # searches the web
+  http-tool: ~1.1.0   # pinned until 1.1 is fixed
+  web-search: '^3.0.0'
+
+kind: agent
+agent:
+  model: {name: gpt-4o}
+`,
+		},
+		{
+			name:     "remove takes the entry's comment along",
+			manifest: commentedManifest,
+			edits:    []DependencyEdit{{Section: "dependencies", Name: "http-tool"}},
+			want: `# My research agent
+name: my-app
+version: 1.0.0
+
+dependencies:
+  web-search: '^2.0.0'
+
+kind: agent
+agent:
+  model: {name: gpt-4o}
+`,
+		},
+		{
+			name:     "removing the last entry removes the section",
+			manifest: "name: a\nversion: 1.0.0\ndependencies:\n  web-search: ^2.0.0\nkind: agent\nagent: {model: {name: m}}\n",
+			edits:    []DependencyEdit{{Section: "dependencies", Name: "web-search"}, {Section: "dependencies", Name: "missing"}},
+			want:     "name: a\nversion: 1.0.0\nkind: agent\nagent: {model: {name: m}}\n",
+		},
+		{
+			name:     "new section",
+			manifest: "name: a # the name\nversion: 1.0.0",
+			edits:    []DependencyEdit{{Section: "devDependencies", Name: "eval-kit", Constraint: "0.3.0"}},
+			want:     "name: a # the name\nversion: 1.0.0\ndevDependencies:\n  eval-kit: 0.3.0\n",
+		},
+		{
+			name:     "windows line endings",
+			manifest: "name: a\r\nversion: 1.0.0\r\ndependencies:\r\n  b: ^1.0.0\r\n",
+			edits:    []DependencyEdit{{Section: "dependencies", Name: "c", Constraint: "^1.0.0"}},
+			want:     "name: a\r\nversion: 1.0.0\r\ndependencies:\r\n  b: ^1.0.0\r\n  c: ^1.0.0\r\n",
+		},
+		{
+			name:     "flow section",
+			manifest: "name: a\nversion: 1.0.0\ndependencies: {b: ^1.0.0} # deps\n",
+			edits:    []DependencyEdit{{Section: "dependencies", Name: "c", Constraint: "^1.0.0"}},
+			want:     "name: a\nversion: 1.0.0\ndependencies: {b: ^1.0.0, c: ^1.0.0} # deps\n",
+		},
+		{
+			name:     "empty section",
+			manifest: "name: a\nversion: 1.0.0\ndependencies:\n",
+			edits:    []DependencyEdit{{Section: "dependencies", Name: "b", Constraint: "^1.0.0"}},
+			want:     "name: a\nversion: 1.0.0\ndependencies:\n  b: ^1.0.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "agentpkg.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(tt.manifest), 0644))
+			require.NoError(t, EditDependencies(file, tt.edits...))
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+}
+
+func TestWithDependencyEdits(t *testing.T) {
+	agentPkg := &AgentPkg{Name: "my-app", Dependencies: map[string]string{"web-search": "^1.0.0"}}
+	edited := agentPkg.WithDependencyEdits(
+		DependencyEdit{Section: "devDependencies", Name: "web-search", Constraint: "^2.0.0"},
+		DependencyEdit{Section: "dependencies", Name: "web-search"})
+	assert.Empty(t, edited.Dependencies)
+	assert.Equal(t, map[string]string{"web-search": "^2.0.0"}, edited.DevDependencies)
+	assert.Equal(t, map[string]string{"web-search": "^1.0.0"}, agentPkg.Dependencies, "the original is unchanged")
+	assert.Nil(t, agentPkg.DevDependencies)
+}
+
+func TestEditDependenciesJSON(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "agentpkg.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"name": "a", "version": "1.0.0", "dependencies": {"b": "^1.0.0"}}`), 0644))
+	require.NoError(t, EditDependencies(file,
+		DependencyEdit{Section: "devDependencies", Name: "b", Constraint: "^1.0.0"},
+		DependencyEdit{Section: "dependencies", Name: "b"}))
+
+	agentPkg, err := LoadAgentPkg(file)
+	require.NoError(t, err)
+	assert.Nil(t, agentPkg.Dependencies)
+	assert.Equal(t, map[string]string{"b": "^1.0.0"}, agentPkg.DevDependencies)
+}
+
+func TestEditDependenciesErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "agentpkg.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("name: a\nversion: 1.0.0\n"), 0644))
+	assert.ErrorContains(t, EditDependencies(file, DependencyEdit{Section: "peerDependencies", Name: "b", Constraint: "*"}),
+		`unknown dependency section "peerDependencies"`)
+	assert.ErrorContains(t, EditDependencies(filepath.Join(t.TempDir(), "agentpkg.yaml")), "failed to read manifest")
+
+	require.NoError(t, os.WriteFile(file, []byte("name: a\nversion: 1.0.0\nhomepage: x\n"), 0644))
+	assert.Error(t, EditDependencies(file, DependencyEdit{Section: "dependencies", Name: "b", Constraint: "*"}))
+}
@@ -0,0 +1,268 @@
+package pkg
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Kind is the kind of package a manifest describes. Each kind has its own
+// manifest section, named after the kind.
+type Kind string
+
+const (
+	KindAgent   Kind = "agent"
+	KindTool    Kind = "tool"
+	KindChain   Kind = "chain"
+	KindPrompt  Kind = "prompt"
+	KindDataset Kind = "dataset"
+)
+
+// Kinds lists every package kind.
+var Kinds = []Kind{KindAgent, KindTool, KindChain, KindPrompt, KindDataset}
+
+// AgentSpec is the agent section: the model an agent needs and the tools
+// it may call.
+type AgentSpec struct {
+	Model *ModelSpec `yaml:"model" json:"model"`
+	// Prompt is the path of the agent's system prompt within the package
+	Prompt string        `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+	Tools  []ToolBinding `yaml:"tools,omitempty" json:"tools,omitempty"`
+}
+
+// ModelSpec describes the model requirements of an agent.
+type ModelSpec struct {
+	Name     string `yaml:"name" json:"name"`
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// ContextWindow is the minimum context window, in tokens
+	ContextWindow int      `yaml:"context_window,omitempty" json:"context_window,omitempty"`
+	Capabilities  []string `yaml:"capabilities,omitempty" json:"capabilities,omitempty"`
+}
+
+// ToolBinding makes a tool package, which must be a dependency, available
+// to an agent under Name.
+type ToolBinding struct {
+	Name    string `yaml:"name,omitempty" json:"name,omitempty"`
+	Package string `yaml:"package" json:"package"`
+}
+
+// ToolSpec is the tool section: how to run a tool and the JSON schemas of
+// its input and output.
+type ToolSpec struct {
+	Entrypoint string                 `yaml:"entrypoint" json:"entrypoint"`
+	Input      map[string]interface{} `yaml:"input" json:"input"`
+	Output     map[string]interface{} `yaml:"output" json:"output"`
+}
+
+// PromptSpec is the prompt section: the prompt templates a package
+// provides.
+type PromptSpec struct {
+	Templates []PromptTemplate `yaml:"templates" json:"templates"`
+}
+
+// PromptTemplate is a prompt template file and the {{variables}} it uses.
+type PromptTemplate struct {
+	Name      string   `yaml:"name" json:"name"`
+	File      string   `yaml:"file" json:"file"`
+	Variables []string `yaml:"variables,omitempty" json:"variables,omitempty"`
+}
+
+// ChainSpec is the chain section: steps run in order.
+type ChainSpec struct {
+	Steps []ChainStep `yaml:"steps" json:"steps"`
+}
+
+// ChainStep runs either a package, which must be a dependency, or a prompt
+// file of the chain itself. With supplies its inputs.
+type ChainStep struct {
+	ID     string            `yaml:"id" json:"id"`
+	Uses   string            `yaml:"uses,omitempty" json:"uses,omitempty"`
+	Prompt string            `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+	With   map[string]string `yaml:"with,omitempty" json:"with,omitempty"`
+}
+
+// DatasetSpec is the dataset section: the data files and their format.
+type DatasetSpec struct {
+	Format  string   `yaml:"format" json:"format"`
+	Files   []string `yaml:"files" json:"files"`
+	Records int      `yaml:"records,omitempty" json:"records,omitempty"`
+}
+
+// DatasetFormats lists the supported dataset formats.
+var DatasetFormats = []string{"jsonl", "json", "csv", "parquet"}
+
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	schemaTypes       = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+)
+
+// checkKind checks the kind of agentPkg and its kind-specific section.
+// A package without a kind is a plain package and may have no section.
+func checkKind(c *checker, agentPkg *AgentPkg) {
+	sections := map[Kind]bool{
+		KindAgent:   agentPkg.Agent != nil,
+		KindTool:    agentPkg.Tool != nil,
+		KindChain:   agentPkg.Chain != nil,
+		KindPrompt:  agentPkg.Prompt != nil,
+		KindDataset: agentPkg.Dataset != nil,
+	}
+	if agentPkg.Kind != "" && !slices.Contains(Kinds, agentPkg.Kind) {
+		c.errorf([]string{"kind"}, "unknown kind %q: must be one of %s", agentPkg.Kind, kindList())
+	}
+	for _, kind := range Kinds {
+		if sections[kind] && kind != agentPkg.Kind {
+			c.keyErrorf([]string{string(kind)}, "%s section is only allowed in packages of kind %s", kind, kind)
+		}
+	}
+	if agentPkg.Kind != "" && slices.Contains(Kinds, agentPkg.Kind) && !sections[agentPkg.Kind] {
+		c.errorf([]string{"kind"}, "packages of kind %s require a %s section", agentPkg.Kind, agentPkg.Kind)
+		return
+	}
+
+	switch agentPkg.Kind {
+	case KindAgent:
+		checkAgent(c, agentPkg.Agent, agentPkg.Dependencies)
+	case KindTool:
+		checkTool(c, agentPkg.Tool)
+	case KindChain:
+		checkChain(c, agentPkg.Chain, agentPkg.Dependencies)
+	case KindPrompt:
+		checkPrompt(c, agentPkg.Prompt)
+	case KindDataset:
+		checkDataset(c, agentPkg.Dataset)
+	}
+}
+
+func kindList() string {
+	return strings.Join(kindNames(), ", ")
+}
+
+func checkAgent(c *checker, spec *AgentSpec, deps map[string]string) {
+	model := []string{"agent", "model"}
+	if spec.Model == nil || spec.Model.Name == "" {
+		c.errorf(fieldPath(model, "name"), "a model name is required")
+	}
+	if spec.Model != nil && spec.Model.ContextWindow < 0 {
+		c.errorf(fieldPath(model, "context_window"), "the context window cannot be negative")
+	}
+	tools := []string{"agent", "tools"}
+	seen := make(map[string]bool)
+	for i, binding := range spec.Tools {
+		if binding.Package == "" {
+			c.errorf(fieldPath(tools, i, "package"), "a tool package is required")
+			continue
+		}
+		if _, ok := deps[binding.Package]; !ok {
+			c.errorf(fieldPath(tools, i, "package"), "tool %s must be listed in dependencies", binding.Package)
+		}
+		name := binding.Name
+		if name == "" {
+			name = binding.Package
+		}
+		if seen[name] {
+			c.errorf(fieldPath(tools, i), "duplicate tool name %q", name)
+		}
+		seen[name] = true
+	}
+}
+
+func checkTool(c *checker, spec *ToolSpec) {
+	if spec.Entrypoint == "" {
+		c.errorf([]string{"tool", "entrypoint"}, "an entrypoint is required")
+	}
+	checkSchema(c, []string{"tool", "input"}, spec.Input)
+	checkSchema(c, []string{"tool", "output"}, spec.Output)
+}
+
+// checkSchema checks that schema looks like a JSON Schema: present, and
+// with a known type when it declares one
+func checkSchema(c *checker, p []string, schema map[string]interface{}) {
+	if len(schema) == 0 {
+		c.errorf(p, "a schema is required")
+		return
+	}
+	t, ok := schema["type"]
+	if !ok {
+		return
+	}
+	name, ok := t.(string)
+	if !ok {
+		c.errorf(fieldPath(p, "type"), "the schema type must be a string")
+	} else if !slices.Contains(schemaTypes, name) {
+		c.errorf(fieldPath(p, "type"), "schema type %q must be one of %s", name, strings.Join(schemaTypes, ", "))
+	}
+}
+
+func checkChain(c *checker, spec *ChainSpec, deps map[string]string) {
+	steps := []string{"chain", "steps"}
+	if len(spec.Steps) == 0 {
+		c.errorf(steps, "a chain needs at least one step")
+		return
+	}
+	seen := make(map[string]bool)
+	for i, step := range spec.Steps {
+		if step.ID == "" {
+			c.errorf(fieldPath(steps, i, "id"), "a step id is required")
+		} else if seen[step.ID] {
+			c.errorf(fieldPath(steps, i, "id"), "duplicate step id %q", step.ID)
+		}
+		seen[step.ID] = true
+		switch {
+		case step.Uses == "" && step.Prompt == "":
+			c.errorf(fieldPath(steps, i), "a step needs either uses or prompt")
+		case step.Uses != "" && step.Prompt != "":
+			c.errorf(fieldPath(steps, i), "a step cannot have both uses and prompt")
+		case step.Uses != "":
+			if _, ok := deps[step.Uses]; !ok {
+				c.errorf(fieldPath(steps, i, "uses"), "package %s must be listed in dependencies", step.Uses)
+			}
+		}
+	}
+}
+
+func checkPrompt(c *checker, spec *PromptSpec) {
+	templates := []string{"prompt", "templates"}
+	if len(spec.Templates) == 0 {
+		c.errorf(templates, "a prompt package needs at least one template")
+		return
+	}
+	seen := make(map[string]bool)
+	for i, tmpl := range spec.Templates {
+		if tmpl.Name == "" {
+			c.errorf(fieldPath(templates, i, "name"), "a template name is required")
+		} else if seen[tmpl.Name] {
+			c.errorf(fieldPath(templates, i, "name"), "duplicate template name %q", tmpl.Name)
+		}
+		seen[tmpl.Name] = true
+		if tmpl.File == "" {
+			c.errorf(fieldPath(templates, i, "file"), "a template file is required")
+		}
+		vars := make(map[string]bool)
+		for j, v := range tmpl.Variables {
+			p := fieldPath(templates, i, "variables", j)
+			if !identifierPattern.MatchString(v) {
+				c.errorf(p, "invalid variable name %q", v)
+			} else if vars[v] {
+				c.errorf(p, "duplicate variable %q", v)
+			}
+			vars[v] = true
+		}
+	}
+}
+
+func checkDataset(c *checker, spec *DatasetSpec) {
+	if !slices.Contains(DatasetFormats, spec.Format) {
+		c.errorf([]string{"dataset", "format"}, "format %q must be one of %s", spec.Format, strings.Join(DatasetFormats, ", "))
+	}
+	if len(spec.Files) == 0 {
+		c.errorf([]string{"dataset", "files"}, "a dataset must list at least one file")
+	}
+	for i, f := range spec.Files {
+		if f == "" {
+			c.errorf(fieldPath([]string{"dataset", "files"}, i), "a file path cannot be empty")
+		}
+	}
+	if spec.Records < 0 {
+		c.errorf([]string{"dataset", "records"}, "the record count cannot be negative")
+	}
+}
@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKindExamplesAreValid(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "kinds", "*"))
+	require.NoError(t, err)
+	require.Len(t, files, len(Kinds))
+
+	for _, file := range files {
+		agentPkg, err := LoadAgentPkg(file)
+		require.NoError(t, err, file)
+		assert.NoError(t, ValidateAgentPkg(agentPkg), file)
+	}
+}
+
+func TestKindSections(t *testing.T) {
+	agentPkg, err := LoadAgentPkg(filepath.Join("testdata", "kinds", "tool.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, KindTool, agentPkg.Kind)
+	assert.Equal(t, "tools/search.py", agentPkg.Tool.Entrypoint)
+	assert.Equal(t, "object", agentPkg.Tool.Input["type"])
+
+	agentPkg, err = LoadAgentPkg(filepath.Join("testdata", "kinds", "chain.json"))
+	require.NoError(t, err)
+	require.Len(t, agentPkg.Chain.Steps, 2)
+	assert.Equal(t, "web-search", agentPkg.Chain.Steps[0].Uses)
+	assert.Equal(t, map[string]string{"query": "{{topic}}"}, agentPkg.Chain.Steps[0].With)
+}
+
+func TestValidateKindErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		err      string
+	}{
+		{"unknown kind", "kind: workflow\n", `unknown kind "workflow"`},
+		{"section without kind", "tool:\n  entrypoint: main.py\n", "tool section is only allowed in packages of kind tool"},
+		{"section of another kind", "kind: agent\nagent:\n  model: {name: m}\ntool:\n  entrypoint: main.py\n", "tool section is only allowed"},
+		{"missing section", "kind: dataset\n", "packages of kind dataset require a dataset section"},
+
+		{"agent without model", "kind: agent\nagent:\n  prompt: p.md\n", "agent.model.name: a model name is required"},
+		{"agent tool not a dependency", "kind: agent\nagent:\n  model: {name: m}\n  tools:\n    - package: search\n", "agent.tools[0].package: tool search must be listed in dependencies"},
+		{"agent duplicate tool", "kind: agent\ndependencies: {a: '*', b: '*'}\nagent:\n  model: {name: m}\n  tools:\n    - {name: t, package: a}\n    - {name: t, package: b}\n", `agent.tools[1]: duplicate tool name "t"`},
+
+		{"tool without entrypoint", "kind: tool\ntool:\n  input: {type: object}\n  output: {type: object}\n", "tool.entrypoint: an entrypoint is required"},
+		{"tool without input", "kind: tool\ntool:\n  entrypoint: main.py\n  output: {type: object}\n", "tool.input: a schema is required"},
+		{"tool bad schema type", "kind: tool\ntool:\n  entrypoint: main.py\n  input: {type: object}\n  output: {type: text}\n", `tool.output.type: schema type "text"`},
+
+		{"chain without steps", "kind: chain\nchain:\n  steps: []\n", "chain.steps: a chain needs at least one step"},
+		{"chain step without id", "kind: chain\nchain:\n  steps:\n    - prompt: p.md\n", "chain.steps[0].id: a step id is required"},
+		{"chain duplicate id", "kind: chain\nchain:\n  steps:\n    - {id: a, prompt: p.md}\n    - {id: a, prompt: q.md}\n", `chain.steps[1].id: duplicate step id "a"`},
+		{"chain step needs action", "kind: chain\nchain:\n  steps:\n    - id: a\n", "needs either uses or prompt"},
+		{"chain step with both", "kind: chain\ndependencies: {x: '*'}\nchain:\n  steps:\n    - {id: a, uses: x, prompt: p.md}\n", "cannot have both uses and prompt"},
+		{"chain uses unknown package", "kind: chain\nchain:\n  steps:\n    - {id: a, uses: x}\n", "chain.steps[0].uses: package x must be listed in dependencies"},
+
+		{"prompt without templates", "kind: prompt\nprompt:\n  templates: []\n", "at least one template"},
+		{"prompt without file", "kind: prompt\nprompt:\n  templates:\n    - name: a\n", "prompt.templates[0].file: a template file is required"},
+		{"prompt bad variable", "kind: prompt\nprompt:\n  templates:\n    - {name: a, file: a.md, variables: [\"two words\"]}\n", `prompt.templates[0].variables[0]: invalid variable name "two words"`},
+		{"prompt duplicate variable", "kind: prompt\nprompt:\n  templates:\n    - {name: a, file: a.md, variables: [x, x]}\n", `prompt.templates[0].variables[1]: duplicate variable "x"`},
+
+		{"dataset bad format", "kind: dataset\ndataset:\n  format: xlsx\n  files: [a.xlsx]\n", `dataset.format: format "xlsx"`},
+		{"dataset without files", "kind: dataset\ndataset:\n  format: csv\n", "dataset.files: a dataset must list at least one file"},
+		{"dataset negative records", "kind: dataset\ndataset:\n  format: csv\n  files: [a.csv]\n  records: -1\n", "dataset.records: the record count cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agentPkg, err := ParseAgentPkg("agentpkg.yaml", []byte("name: a\nversion: 1.0.0\n"+tt.manifest))
+			require.NoError(t, err)
+			err = ValidateAgentPkg(agentPkg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
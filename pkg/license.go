@@ -0,0 +1,167 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// spdxLicenses are the SPDX license identifiers recognised in the license
+// field: the licenses commonly used for code, prompts and datasets.
+var spdxLicenses = []string{
+	"0BSD", "AFL-3.0", "AGPL-3.0-only", "AGPL-3.0-or-later", "Apache-1.1",
+	"Apache-2.0", "Artistic-2.0", "BSD-2-Clause", "BSD-3-Clause",
+	"BSD-3-Clause-Clear", "BSL-1.0", "CC-BY-4.0", "CC-BY-NC-4.0",
+	"CC-BY-NC-SA-4.0", "CC-BY-ND-4.0", "CC-BY-SA-4.0", "CC0-1.0", "CDDL-1.0",
+	"EPL-1.0", "EPL-2.0", "EUPL-1.2", "GPL-2.0-only", "GPL-2.0-or-later",
+	"GPL-3.0-only", "GPL-3.0-or-later", "ISC", "LGPL-2.1-only",
+	"LGPL-2.1-or-later", "LGPL-3.0-only", "LGPL-3.0-or-later", "MIT", "MIT-0",
+	"MPL-2.0", "MS-PL", "NCSA", "ODbL-1.0", "ODC-By-1.0", "OFL-1.1",
+	"PDDL-1.0", "PostgreSQL", "UPL-1.0", "Unlicense", "WTFPL", "Zlib",
+}
+
+// deprecatedLicenses maps deprecated SPDX identifiers to their
+// replacements.
+var deprecatedLicenses = map[string]string{
+	"GPL-2.0":  "GPL-2.0-only or GPL-2.0-or-later",
+	"GPL-3.0":  "GPL-3.0-only or GPL-3.0-or-later",
+	"LGPL-2.1": "LGPL-2.1-only or LGPL-2.1-or-later",
+	"LGPL-3.0": "LGPL-3.0-only or LGPL-3.0-or-later",
+	"AGPL-3.0": "AGPL-3.0-only or AGPL-3.0-or-later",
+}
+
+// spdxExceptions are the exceptions recognised after WITH.
+var spdxExceptions = []string{
+	"Classpath-exception-2.0", "GCC-exception-3.1", "LLVM-exception",
+}
+
+var (
+	spdxIDPattern  = regexp.MustCompile(`^[A-Za-z0-9.-]+\+?$`)
+	spdxRefPattern = regexp.MustCompile(`^(DocumentRef-[A-Za-z0-9.-]+:)?LicenseRef-[A-Za-z0-9.-]+$`)
+)
+
+// checkLicense checks that license is an SPDX license expression, such as
+// "MIT" or "(Apache-2.0 OR MIT)". "UNLICENSED" marks a package that is not
+// licensed for use by others. Malformed expressions are errors; identifiers
+// missing from the recognised list are only warnings, since the list is
+// not the full SPDX license list.
+func checkLicense(c *checker, license string) {
+	p := []string{"license"}
+	if strings.TrimSpace(license) == "" {
+		c.warnf(p, "no license given; use an SPDX identifier such as MIT, or UNLICENSED")
+		return
+	}
+	if license == "UNLICENSED" {
+		return
+	}
+
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(license))
+	parser := &spdxParser{tokens: tokens}
+	if err := parser.expression(); err == nil && parser.pos < len(tokens) {
+		c.errorf(p, "invalid SPDX license expression %q: unexpected %q", license, tokens[parser.pos])
+		return
+	} else if err != nil {
+		c.errorf(p, "invalid SPDX license expression %q: %v", license, err)
+		return
+	}
+
+	for _, id := range parser.licenses {
+		id = strings.TrimSuffix(id, "+")
+		if spdxRefPattern.MatchString(id) {
+			continue
+		}
+		if replacement, ok := deprecatedLicenses[id]; ok {
+			c.warnf(p, "license %s is deprecated; use %s", id, replacement)
+			continue
+		}
+		if canonical, ok := lookupFold(spdxLicenses, id); !ok {
+			c.warnf(p, "%q is not a recognised SPDX license identifier", id)
+		} else if canonical != id {
+			c.warnf(p, "license %s should be written %s", id, canonical)
+		}
+	}
+	for _, id := range parser.exceptions {
+		if _, ok := lookupFold(spdxExceptions, id); !ok {
+			c.warnf(p, "%q is not a recognised SPDX license exception", id)
+		}
+	}
+}
+
+// lookupFold finds s in list, ignoring case as SPDX identifiers do, and
+// returns the listed spelling.
+func lookupFold(list []string, s string) (string, bool) {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return item, true
+		}
+	}
+	return "", false
+}
+
+// spdxParser parses the SPDX license expression grammar:
+//
+//	expression = term { ("AND" | "OR") term }
+//	term       = "(" expression ")" | id [ "WITH" exception ]
+type spdxParser struct {
+	tokens     []string
+	pos        int
+	licenses   []string
+	exceptions []string
+}
+
+func (p *spdxParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok
+}
+
+func (p *spdxParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *spdxParser) expression() error {
+	if err := p.term(); err != nil {
+		return err
+	}
+	for p.peek() == "AND" || p.peek() == "OR" {
+		p.next()
+		if err := p.term(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *spdxParser) term() error {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return fmt.Errorf("expected a license identifier")
+	case tok == "(":
+		if err := p.expression(); err != nil {
+			return err
+		}
+		if p.next() != ")" {
+			return fmt.Errorf("missing )")
+		}
+		return nil
+	case tok == ")" || tok == "AND" || tok == "OR" || tok == "WITH" || !spdxIDPattern.MatchString(tok) && !spdxRefPattern.MatchString(tok):
+		return fmt.Errorf("unexpected %q", tok)
+	}
+	p.licenses = append(p.licenses, tok)
+	if p.peek() == "WITH" {
+		p.next()
+		exception := p.next()
+		if exception == "" || !spdxIDPattern.MatchString(exception) {
+			return fmt.Errorf("expected an exception after WITH")
+		}
+		p.exceptions = append(p.exceptions, exception)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLicense(t *testing.T) {
+	tests := []struct {
+		license  string
+		severity Severity
+		message  string
+	}{
+		{"MIT", "", ""},
+		{"UNLICENSED", "", ""},
+		{"(Apache-2.0 OR MIT)", "", ""},
+		{"GPL-2.0-or-later WITH Classpath-exception-2.0", "", ""},
+		{"Apache-2.0 AND (MIT OR BSD-3-Clause)", "", ""},
+		{"LicenseRef-Acme-Proprietary", "", ""},
+		{"DocumentRef-spdx-tool:LicenseRef-Acme", "", ""},
+		{"", SeverityWarning, "no license given"},
+		{"mit", SeverityWarning, "should be written MIT"},
+		{"GPL-3.0", SeverityWarning, "is deprecated"},
+		{"Acme-1.0", SeverityWarning, "not a recognised SPDX license identifier"},
+		{"MIT WITH Acme-exception", SeverityWarning, "not a recognised SPDX license exception"},
+		{"MIT OR", SeverityError, "expected a license identifier"},
+		{"(MIT OR Apache-2.0", SeverityError, "missing )"},
+		{"MIT Apache-2.0", SeverityError, `unexpected "Apache-2.0"`},
+		{"MIT/Apache-2.0", SeverityError, "invalid SPDX license expression"},
+		{"MIT WITH", SeverityError, "expected an exception after WITH"},
+	}
+
+	for _, tt := range tests {
+		c := &checker{}
+		checkLicense(c, tt.license)
+		if tt.message == "" {
+			assert.Empty(t, c.problems, tt.license)
+			continue
+		}
+		require.Len(t, c.problems, 1, tt.license)
+		assert.Equal(t, "license", c.problems[0].Field)
+		assert.Equal(t, tt.severity, c.problems[0].Severity, tt.license)
+		assert.Contains(t, c.problems[0].Message, tt.message, tt.license)
+	}
+}
@@ -0,0 +1,236 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockfileName is the name of the lockfile written next to the manifest.
+const LockfileName = "agenthub.lock"
+
+// LockfileVersion is the lockfile format version written by this release.
+const LockfileVersion = 1
+
+var lockDigestPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+const lockfileHeader = "# This file is generated by `agenthub install`. Do not edit it by hand.\n"
+
+// Lockfile records the exact package versions an install resolved to, so
+// every install of the same manifest produces the same tree.
+type Lockfile struct {
+	LockfileVersion int `yaml:"lockfileVersion"`
+	// Dependencies is a copy of the manifest ranges the lock was resolved
+	// from, used to detect a lock that no longer matches the manifest.
+	Dependencies    map[string]string         `yaml:"dependencies,omitempty"`
+	DevDependencies map[string]string         `yaml:"devDependencies,omitempty"`
+	Packages        map[string]*LockedPackage `yaml:"packages,omitempty"`
+}
+
+// LockedPackage is one resolved package in a lockfile.
+type LockedPackage struct {
+	Version      string            `yaml:"version"`
+	Registry     string            `yaml:"registry"`
+	Digest       string            `yaml:"digest"`
+	Dependencies map[string]string `yaml:"dependencies,omitempty"`
+}
+
+// LoadLockfile reads a lockfile from disk.
+func LoadLockfile(filename string) (*Lockfile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var lock Lockfile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if lock.LockfileVersion != LockfileVersion {
+		return nil, fmt.Errorf("%s: unsupported lockfile version %d", filename, lock.LockfileVersion)
+	}
+	if err := lock.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &lock, nil
+}
+
+// validate checks the names, versions and digests of the locked packages.
+// The names become paths in agent_modules, so a lock that was edited to
+// hold anything else is refused rather than installed.
+func (l *Lockfile) validate() error {
+	for _, deps := range []map[string]string{l.Dependencies, l.DevDependencies} {
+		for _, name := range sortedKeys(deps) {
+			if err := ValidateName(name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range sortedKeys(l.Packages) {
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+		p := l.Packages[name]
+		if p == nil {
+			return fmt.Errorf("package %s has no version", name)
+		}
+		if _, err := ParseVersion(p.Version); err != nil {
+			return fmt.Errorf("package %s: %w", name, err)
+		}
+		if !lockDigestPattern.MatchString(p.Digest) {
+			return fmt.Errorf("package %s@%s has invalid digest %q", name, p.Version, p.Digest)
+		}
+		for _, dep := range sortedKeys(p.Dependencies) {
+			if err := ValidateName(dep); err != nil {
+				return fmt.Errorf("package %s@%s: %w", name, p.Version, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Save writes the lockfile to disk. Map keys are sorted, so the output only
+// changes when the resolved tree does.
+func (l *Lockfile) Save(filename string) error {
+	var buf bytes.Buffer
+	buf.WriteString(lockfileHeader)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(l); err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+// Preferred returns the locked version of every package, for use as
+// Solver.Preferred when the lock has to be re-resolved.
+func (l *Lockfile) Preferred() map[string]string {
+	preferred := make(map[string]string, len(l.Packages))
+	for name, p := range l.Packages {
+		preferred[name] = p.Version
+	}
+	return preferred
+}
+
+// Resolved returns the locked packages in the form produced by the solver,
+// sorted by name.
+func (l *Lockfile) Resolved() []Resolved {
+	resolved := make([]Resolved, 0, len(l.Packages))
+	for name, p := range l.Packages {
+		resolved = append(resolved, Resolved{Name: name, Version: p.Version, Dependencies: p.Dependencies})
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].Name < resolved[j].Name
+	})
+	return resolved
+}
+
+// Verify checks that the lockfile was resolved from the dependencies in
+// agentPkg and is internally complete. It returns an error describing
+// every disagreement it finds.
+func (l *Lockfile) Verify(agentPkg *AgentPkg) error {
+	var problems []string
+
+	compare := func(where string, manifest, locked map[string]string) {
+		for _, name := range sortedKeys(manifest) {
+			want := manifest[name]
+			got, ok := locked[name]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("%s %s is in the %s but not in the lockfile", name, want, where))
+			case got != want:
+				problems = append(problems, fmt.Sprintf("%s is %s in the %s but %s in the lockfile", name, want, where, got))
+			}
+		}
+		for _, name := range sortedKeys(locked) {
+			if _, ok := manifest[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s is in the lockfile but no longer in the %s", name, where))
+			}
+		}
+	}
+	compare("manifest", agentPkg.Dependencies, l.Dependencies)
+	compare("manifest's devDependencies", agentPkg.DevDependencies, l.DevDependencies)
+
+	check := func(from, name, rng string) {
+		locked, ok := l.Packages[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s requires %s, which is not locked", from, name))
+			return
+		}
+		c, err := ParseConstraint(rng)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", from, err))
+			return
+		}
+		v, err := ParseVersion(locked.Version)
+		if err != nil || !c.Check(v) {
+			problems = append(problems, fmt.Sprintf("%s requires %s %s, but %s is locked", from, name, rng, locked.Version))
+		}
+	}
+	for _, name := range sortedKeys(l.Dependencies) {
+		check(agentPkg.Name, name, l.Dependencies[name])
+	}
+	for _, name := range sortedKeys(l.DevDependencies) {
+		check(agentPkg.Name, name, l.DevDependencies[name])
+	}
+	for _, name := range sortedKeys(l.Packages) {
+		p := l.Packages[name]
+		for _, dep := range sortedKeys(p.Dependencies) {
+			check(name+"@"+p.Version, dep, p.Dependencies[dep])
+		}
+	}
+
+	// Every locked package must be needed by the manifest; an extra one
+	// would be installed without anything asking for it.
+	reached := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		p, ok := l.Packages[name]
+		if !ok || reached[name] {
+			return
+		}
+		reached[name] = true
+		for _, dep := range sortedKeys(p.Dependencies) {
+			visit(dep)
+		}
+	}
+	for _, name := range sortedKeys(l.Dependencies) {
+		visit(name)
+	}
+	for _, name := range sortedKeys(l.DevDependencies) {
+		visit(name)
+	}
+	for _, name := range sortedKeys(l.Packages) {
+		if !reached[name] {
+			problems = append(problems, fmt.Sprintf("%s@%s is locked, but nothing depends on it", name, l.Packages[name].Version))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s does not match the manifest:\n  %s", LockfileName, strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	digestA = "sha256:" + strings.Repeat("a", 64)
+	digestB = "sha256:" + strings.Repeat("b", 64)
+	digestC = "sha256:" + strings.Repeat("c", 64)
+)
+
+func sampleLockfile() *Lockfile {
+	return &Lockfile{
+		LockfileVersion: LockfileVersion,
+		Dependencies:    map[string]string{"web-search": "^2.0.0"},
+		Packages: map[string]*LockedPackage{
+			"web-search": {Version: "2.1.0", Registry: "memory", Digest: digestA, Dependencies: map[string]string{"http-tool": "~1.0.0"}},
+			"http-tool":  {Version: "1.0.3", Registry: "memory", Digest: digestB},
+		},
+	}
+}
+
+func TestLockfileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockfileName)
+	lock := sampleLockfile()
+	require.NoError(t, lock.Save(path))
+
+	loaded, err := LoadLockfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, lock, loaded)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, lockfileHeader+`lockfileVersion: 1
+dependencies:
+  web-search: ^2.0.0
+packages:
+  http-tool:
+    version: 1.0.3
+    registry: memory
+    digest: `+digestB+`
+  web-search:
+    version: 2.1.0
+    registry: memory
+    digest: `+digestA+`
+    dependencies:
+      http-tool: ~1.0.0
+`, string(data))
+}
+
+func TestLoadLockfileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := LoadLockfile(filepath.Join(dir, "missing.lock"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	path := filepath.Join(dir, LockfileName)
+	require.NoError(t, os.WriteFile(path, []byte("lockfileVersion: 99\n"), 0644))
+	_, err = LoadLockfile(path)
+	assert.ErrorContains(t, err, "unsupported lockfile version 99")
+
+	require.NoError(t, os.WriteFile(path, []byte("lockfileVersion: 1\nextra: true\n"), 0644))
+	_, err = LoadLockfile(path)
+	assert.ErrorContains(t, err, "field extra not found")
+
+	for _, c := range []struct{ entry, want string }{
+		{`"../../victim": {version: 1.0.0, registry: memory, digest: ` + digestA + `}`, `invalid package name "../../victim"`},
+		{`web-search: {version: latest, registry: memory, digest: ` + digestA + `}`, `package web-search: invalid semantic version "latest"`},
+		{`web-search: {version: 1.0.0, registry: memory, digest: sha256:aa}`, `web-search@1.0.0 has invalid digest "sha256:aa"`},
+		{`web-search: {version: 1.0.0, registry: memory, digest: ` + digestA + `, dependencies: {"../x": ^1.0.0}}`, `invalid package name "../x"`},
+		{`web-search: ~`, "package web-search has no version"},
+	} {
+		require.NoError(t, os.WriteFile(path, []byte("lockfileVersion: 1\npackages:\n  "+c.entry+"\n"), 0644))
+		_, err = LoadLockfile(path)
+		assert.ErrorContains(t, err, c.want, c.entry)
+	}
+}
+
+func TestLockfileVerify(t *testing.T) {
+	lock := sampleLockfile()
+	agentPkg := &AgentPkg{Name: "my-app", Dependencies: map[string]string{"web-search": "^2.0.0"}}
+	assert.NoError(t, lock.Verify(agentPkg))
+
+	agentPkg.Dependencies = map[string]string{"web-search": "^3.0.0", "summarizer": "^1.0.0"}
+	err := lock.Verify(agentPkg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "summarizer ^1.0.0 is in the manifest but not in the lockfile")
+	assert.Contains(t, err.Error(), "web-search is ^3.0.0 in the manifest but ^2.0.0 in the lockfile")
+
+	agentPkg.Dependencies = nil
+	assert.ErrorContains(t, lock.Verify(agentPkg), "web-search is in the lockfile but no longer in the manifest")
+
+	lock = sampleLockfile()
+	delete(lock.Packages, "http-tool")
+	agentPkg.Dependencies = map[string]string{"web-search": "^2.0.0"}
+	assert.ErrorContains(t, lock.Verify(agentPkg), "web-search@2.1.0 requires http-tool, which is not locked")
+
+	lock = sampleLockfile()
+	lock.Packages["http-tool"].Version = "1.1.0"
+	assert.ErrorContains(t, lock.Verify(agentPkg), "web-search@2.1.0 requires http-tool ~1.0.0, but 1.1.0 is locked")
+
+	lock = sampleLockfile()
+	lock.Packages["unused"] = &LockedPackage{Version: "1.0.0", Registry: "memory", Digest: digestC}
+	assert.ErrorContains(t, lock.Verify(agentPkg), "unused@1.0.0 is locked, but nothing depends on it")
+}
+
+func TestLockfileVerifyDevDependencies(t *testing.T) {
+	lock := sampleLockfile()
+	lock.DevDependencies = map[string]string{"eval-kit": "^0.3.0"}
+	lock.Packages["eval-kit"] = &LockedPackage{Version: "0.3.1", Registry: "memory", Digest: digestC}
+	agentPkg := &AgentPkg{
+		Name:            "my-app",
+		Dependencies:    map[string]string{"web-search": "^2.0.0"},
+		DevDependencies: map[string]string{"eval-kit": "^0.3.0"},
+	}
+	assert.NoError(t, lock.Verify(agentPkg))
+
+	agentPkg.DevDependencies = map[string]string{"eval-kit": "^0.4.0"}
+	assert.ErrorContains(t, lock.Verify(agentPkg), "eval-kit is ^0.4.0 in the manifest's devDependencies but ^0.3.0 in the lockfile")
+
+	// Moving a package between the sections changes the lock.
+	agentPkg.DevDependencies = nil
+	agentPkg.Dependencies["eval-kit"] = "^0.3.0"
+	err := lock.Verify(agentPkg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "eval-kit ^0.3.0 is in the manifest but not in the lockfile")
+	assert.Contains(t, err.Error(), "eval-kit is in the lockfile but no longer in the manifest's devDependencies")
+
+	lock.Packages["eval-kit"].Version = "0.4.0"
+	agentPkg.Dependencies = map[string]string{"web-search": "^2.0.0"}
+	agentPkg.DevDependencies = map[string]string{"eval-kit": "^0.3.0"}
+	assert.ErrorContains(t, lock.Verify(agentPkg), "my-app requires eval-kit ^0.3.0, but 0.4.0 is locked")
+}
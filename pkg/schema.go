@@ -0,0 +1,211 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SchemaDialect is the JSON Schema draft the manifest schema is written
+// in; it is the one editors' YAML language servers support best.
+const SchemaDialect = "http://json-schema.org/draft-07/schema#"
+
+// schemaHints adds what the Go types cannot express to the schema of the
+// field at each path: descriptions and the constraints ValidateAgentPkg
+// checks. Paths are written as in Problem.Field, with "[]" for the items
+// of a list.
+var schemaHints = map[string]map[string]interface{}{
+	"": {
+		"title":       "AgentHub package manifest",
+		"description": "The agentpkg.yaml manifest of an AgentHub package.",
+	},
+	"name": {
+		"description": "Package name: lowercase letters, digits, \".\", \"_\" and \"-\", optionally scoped as @scope/name.",
+		"pattern":     namePattern.String(),
+		"maxLength":   MaxNameLength,
+		"not":         map[string]interface{}{"enum": stringsToValues(ReservedNames)},
+	},
+	"version": {
+		"description": "Semantic version of the package, such as 1.2.3.",
+		"pattern":     versionRe.String(),
+	},
+	"description": {"description": "One-line summary shown in search results."},
+	"author":      {"description": "Author as \"Name <email> (url)\"; the email and URL are optional."},
+	"license":     {"description": "SPDX license expression, such as MIT or (Apache-2.0 OR MIT), or UNLICENSED."},
+	"registry":    {"description": "Registry the package's dependencies are installed from."},
+	"dependencies": {
+		"description":   "Packages this package depends on, mapped to version ranges such as ^1.2.0.",
+		"propertyNames": map[string]interface{}{"pattern": namePattern.String()},
+	},
+	"devDependencies": {
+		"description":   "Packages only needed to work on this package, mapped to version ranges. They are not installed for packages that depend on it.",
+		"propertyNames": map[string]interface{}{"pattern": namePattern.String()},
+	},
+	"kind": {
+		"description": "Kind of package. Each kind has a section of the same name.",
+		"enum":        stringsToValues(kindNames()),
+	},
+
+	"agent":                      {"description": "Settings of an agent package."},
+	"agent.model":                {"description": "Model the agent needs."},
+	"agent.model.name":           {"description": "Model name, such as gpt-4o.", "minLength": 1},
+	"agent.model.provider":       {"description": "Model provider, such as openai."},
+	"agent.model.context_window": {"description": "Minimum context window, in tokens.", "minimum": 0},
+	"agent.model.capabilities":   {"description": "Capabilities the model must have, such as tool_use."},
+	"agent.prompt":               {"description": "Path of the system prompt within the package."},
+	"agent.tools":                {"description": "Tool packages the agent may call. Each must be a dependency."},
+	"agent.tools[].name":         {"description": "Name the agent calls the tool by; defaults to the package name."},
+	"agent.tools[].package":      {"description": "Tool package, listed in dependencies.", "minLength": 1},
+
+	"tool":            {"description": "Settings of a tool package."},
+	"tool.entrypoint": {"description": "Path of the program that runs the tool.", "minLength": 1},
+	"tool.input":      {"description": "JSON Schema of the tool's input.", "minProperties": 1},
+	"tool.output":     {"description": "JSON Schema of the tool's output.", "minProperties": 1},
+
+	"chain":       {"description": "Settings of a chain package."},
+	"chain.steps": {"description": "Steps run in order.", "minItems": 1},
+	"chain.steps[]": {
+		"description": "A step runs either another package, with uses, or a prompt of the chain, with prompt.",
+		"oneOf": []interface{}{
+			map[string]interface{}{"required": []interface{}{"uses"}},
+			map[string]interface{}{"required": []interface{}{"prompt"}},
+		},
+	},
+	"chain.steps[].id":     {"description": "Unique step id, used to refer to its output.", "minLength": 1},
+	"chain.steps[].uses":   {"description": "Package the step runs, listed in dependencies."},
+	"chain.steps[].prompt": {"description": "Path of the prompt the step runs within the package."},
+	"chain.steps[].with":   {"description": "Inputs of the step."},
+
+	"prompt":                       {"description": "Settings of a prompt package."},
+	"prompt.templates":             {"description": "Prompt templates the package provides.", "minItems": 1},
+	"prompt.templates[].name":      {"description": "Unique template name.", "minLength": 1},
+	"prompt.templates[].file":      {"description": "Path of the template file within the package.", "minLength": 1},
+	"prompt.templates[].variables": {"description": "The {{variables}} the template uses.", "uniqueItems": true},
+	"prompt.templates[].variables[]": {
+		"pattern": identifierPattern.String(),
+	},
+
+	"dataset":         {"description": "Settings of a dataset package."},
+	"dataset.format":  {"description": "Format of the data files.", "enum": stringsToValues(DatasetFormats)},
+	"dataset.files":   {"description": "Paths of the data files within the package.", "minItems": 1},
+	"dataset.files[]": {"minLength": 1},
+	"dataset.records": {"description": "Number of records in the dataset.", "minimum": 0},
+}
+
+// Schema returns the JSON Schema of the manifest format. It is generated
+// from the AgentPkg type, so every field a manifest may have is in it and
+// any other is rejected, and carries the constraints ValidateAgentPkg
+// checks that a schema can express.
+func Schema() map[string]interface{} {
+	s := typeSchema(reflect.TypeOf(AgentPkg{}), "")
+	s["$schema"] = SchemaDialect
+
+	// A package of a kind must have that kind's section and no other.
+	var rules []interface{}
+	for _, kind := range Kinds {
+		rules = append(rules, map[string]interface{}{
+			"if": map[string]interface{}{
+				"properties": map[string]interface{}{"kind": map[string]interface{}{"const": string(kind)}},
+				"required":   []interface{}{"kind"},
+			},
+			"then": map[string]interface{}{"required": []interface{}{string(kind)}},
+			"else": map[string]interface{}{"not": map[string]interface{}{"required": []interface{}{string(kind)}}},
+		})
+	}
+	s["allOf"] = rules
+	return s
+}
+
+// MarshalSchema returns Schema as indented JSON.
+func MarshalSchema() ([]byte, error) {
+	data, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// typeSchema returns the schema of values of type t, found at path in a
+// manifest.
+func typeSchema(t reflect.Type, path string) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var s map[string]interface{}
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		var required []interface{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = typeSchema(field.Type, joinSchemaPath(path, name))
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		s = map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+	case reflect.Map:
+		s = map[string]interface{}{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			s["additionalProperties"] = typeSchema(t.Elem(), path+".*")
+		}
+	case reflect.Slice:
+		s = map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem(), path+"[]"),
+		}
+	case reflect.String:
+		s = map[string]interface{}{"type": "string"}
+	case reflect.Int, reflect.Int64:
+		s = map[string]interface{}{"type": "integer"}
+	case reflect.Bool:
+		s = map[string]interface{}{"type": "boolean"}
+	default:
+		s = map[string]interface{}{}
+	}
+
+	for key, value := range schemaHints[path] {
+		s[key] = value
+	}
+	return s
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// kindNames returns the names of Kinds.
+func kindNames() []string {
+	names := make([]string, len(Kinds))
+	for i, k := range Kinds {
+		names[i] = string(k)
+	}
+	return names
+}
+
+func stringsToValues(list []string) []interface{} {
+	values := make([]interface{}, len(list))
+	for i, s := range list {
+		values[i] = s
+	}
+	return values
+}
@@ -0,0 +1,302 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// validateSchema checks value against the subset of JSON Schema draft-07
+// that Schema uses, returning the first violation.
+func validateSchema(schema map[string]interface{}, value interface{}, at string) error {
+	if t, ok := schema["type"].(string); ok {
+		if got := jsonType(value); got != t && !(t == "number" && got == "integer") {
+			return fmt.Errorf("%s: expected %s, got %s", at, t, got)
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || reflect.DeepEqual(e, value)
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", at, value, enum)
+		}
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		return fmt.Errorf("%s: %v is not %v", at, value, c)
+	}
+	if p, ok := schema["pattern"].(string); ok {
+		if s, isString := value.(string); isString && !regexp.MustCompile(p).MatchString(s) {
+			return fmt.Errorf("%s: %q does not match %s", at, s, p)
+		}
+	}
+	if s, ok := value.(string); ok {
+		if n, ok := schema["minLength"].(int); ok && len(s) < n {
+			return fmt.Errorf("%s: shorter than %d", at, n)
+		}
+		if n, ok := schema["maxLength"].(int); ok && len(s) > n {
+			return fmt.Errorf("%s: longer than %d", at, n)
+		}
+	}
+	if n, ok := schema["minimum"].(int); ok {
+		if f, isNumber := value.(float64); isNumber && f < float64(n) {
+			return fmt.Errorf("%s: less than %d", at, n)
+		}
+	}
+
+	if obj, ok := value.(map[string]interface{}); ok {
+		if n, ok := schema["minProperties"].(int); ok && len(obj) < n {
+			return fmt.Errorf("%s: fewer than %d properties", at, n)
+		}
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				if _, ok := obj[r.(string)]; !ok {
+					return fmt.Errorf("%s: %s is required", at, r)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, v := range obj {
+			if names, ok := schema["propertyNames"].(map[string]interface{}); ok {
+				if err := validateSchema(names, key, at+"."+key); err != nil {
+					return err
+				}
+			}
+			if p, ok := properties[key].(map[string]interface{}); ok {
+				if err := validateSchema(p, v, at+"."+key); err != nil {
+					return err
+				}
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s: unknown field %s", at, key)
+				}
+			case map[string]interface{}:
+				if err := validateSchema(additional, v, at+"."+key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if list, ok := value.([]interface{}); ok {
+		if n, ok := schema["minItems"].(int); ok && len(list) < n {
+			return fmt.Errorf("%s: fewer than %d items", at, n)
+		}
+		if unique, _ := schema["uniqueItems"].(bool); unique {
+			for i := range list {
+				for j := i + 1; j < len(list); j++ {
+					if reflect.DeepEqual(list[i], list[j]) {
+						return fmt.Errorf("%s: duplicate item %v", at, list[i])
+					}
+				}
+			}
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, v := range list {
+				if err := validateSchema(items, v, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if err := validateSchema(sub.(map[string]interface{}), value, at); err != nil {
+				return err
+			}
+		}
+	}
+	if one, ok := schema["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, sub := range one {
+			if validateSchema(sub.(map[string]interface{}), value, at) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matches %d of oneOf", at, matched)
+		}
+	}
+	if not, ok := schema["not"].(map[string]interface{}); ok && validateSchema(not, value, at) == nil {
+		return fmt.Errorf("%s: matches not", at)
+	}
+	if cond, ok := schema["if"].(map[string]interface{}); ok {
+		branch := "else"
+		if validateSchema(cond, value, at) == nil {
+			branch = "then"
+		}
+		if sub, ok := schema[branch].(map[string]interface{}); ok {
+			if err := validateSchema(sub, value, at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// loadInstance decodes a manifest into the generic values a JSON Schema
+// validator sees, going through JSON so numbers are float64 for YAML too.
+func loadInstance(t *testing.T, name string, data []byte) interface{} {
+	t.Helper()
+	if !strings.HasSuffix(name, ".json") {
+		var v interface{}
+		require.NoError(t, yaml.Unmarshal(data, &v), name)
+		var err error
+		data, err = json.Marshal(v)
+		require.NoError(t, err, name)
+	}
+	var v interface{}
+	require.NoError(t, json.Unmarshal(data, &v), name)
+	return v
+}
+
+// emittedSchema returns the schema as agenthub schema prints it, decoded
+// again, with whole numbers decoded as int.
+func emittedSchema(t *testing.T) map[string]interface{} {
+	t.Helper()
+	data, err := MarshalSchema()
+	require.NoError(t, err)
+	var s interface{}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	require.NoError(t, dec.Decode(&s))
+	return intNumbers(s).(map[string]interface{})
+}
+
+func intNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = intNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = intNumbers(e)
+		}
+	}
+	return v
+}
+
+func TestSchemaAcceptsSampleManifests(t *testing.T) {
+	schema := emittedSchema(t)
+	assert.Equal(t, SchemaDialect, schema["$schema"])
+
+	files, err := filepath.Glob(filepath.Join("testdata", "*.*"))
+	require.NoError(t, err)
+	kinds, err := filepath.Glob(filepath.Join("testdata", "kinds", "*.*"))
+	require.NoError(t, err)
+	for _, file := range append(files, kinds...) {
+		if strings.HasPrefix(filepath.Base(file), "unknown_field") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		agentPkg, err := ParseAgentPkg(file, data)
+		require.NoError(t, err, file)
+		require.NoError(t, ValidateAgentPkg(agentPkg), file)
+		assert.NoError(t, validateSchema(schema, loadInstance(t, file, data), "$"), file)
+	}
+}
+
+func TestSchemaRejectsInvalidManifests(t *testing.T) {
+	schema := emittedSchema(t)
+	tests := []struct {
+		name     string
+		manifest string
+	}{
+		{"unknown field", "name: a\nversion: 1.0.0\nhomepage: x\n"},
+		{"missing version", "name: a\n"},
+		{"bad name", "name: My Agent\nversion: 1.0.0\n"},
+		{"reserved name", "name: agenthub\nversion: 1.0.0\n"},
+		{"bad version", "name: a\nversion: \"1.0\"\n"},
+		{"bad dependency name", "name: a\nversion: 1.0.0\ndependencies: {Bad: '*'}\n"},
+		{"unknown kind", "name: a\nversion: 1.0.0\nkind: workflow\n"},
+		{"missing section", "name: a\nversion: 1.0.0\nkind: dataset\n"},
+		{"section of another kind", "name: a\nversion: 1.0.0\ntool: {entrypoint: m, input: {type: object}, output: {type: object}}\n"},
+		{"agent without model", "name: a\nversion: 1.0.0\nkind: agent\nagent: {prompt: p.md}\n"},
+		{"negative context window", "name: a\nversion: 1.0.0\nkind: agent\nagent: {model: {name: m, context_window: -1}}\n"},
+		{"tool without input", "name: a\nversion: 1.0.0\nkind: tool\ntool: {entrypoint: m, output: {type: object}}\n"},
+		{"chain without steps", "name: a\nversion: 1.0.0\nkind: chain\nchain: {steps: []}\n"},
+		{"chain step with both", "name: a\nversion: 1.0.0\nkind: chain\nchain: {steps: [{id: a, uses: x, prompt: p.md}]}\n"},
+		{"prompt bad variable", "name: a\nversion: 1.0.0\nkind: prompt\nprompt: {templates: [{name: a, file: a.md, variables: [\"two words\"]}]}\n"},
+		{"dataset bad format", "name: a\nversion: 1.0.0\nkind: dataset\ndataset: {format: xlsx, files: [a]}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The schema and the validator agree: both reject the manifest.
+			assert.Error(t, validateSchema(schema, loadInstance(t, "agentpkg.yaml", []byte(tt.manifest)), "$"))
+			agentPkg, err := ParseAgentPkg("agentpkg.yaml", []byte(tt.manifest))
+			if err == nil {
+				err = ValidateAgentPkg(agentPkg)
+			}
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSchemaCoversAgentPkg(t *testing.T) {
+	schema := Schema()
+
+	// Every hint applies to a field of the generated schema, so renaming
+	// a field cannot leave its constraints behind.
+	paths := make(map[string]bool)
+	var walk func(s map[string]interface{}, path string)
+	walk = func(s map[string]interface{}, path string) {
+		paths[path] = true
+		if properties, ok := s["properties"].(map[string]interface{}); ok {
+			for name, p := range properties {
+				walk(p.(map[string]interface{}), joinSchemaPath(path, name))
+			}
+		}
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			walk(items, path+"[]")
+		}
+	}
+	walk(schema, "")
+	for path := range schemaHints {
+		assert.True(t, paths[path], "hint for unknown field %q", path)
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Len(t, properties, reflect.TypeOf(AgentPkg{}).NumField())
+	assert.Equal(t, []interface{}{"name", "version"}, schema["required"])
+}
@@ -0,0 +1,506 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version (https://semver.org).
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      string
+}
+
+var versionRe = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// ParseVersion parses a full semantic version such as "1.2.3-beta.1+build.5".
+// A leading "v" is accepted.
+func ParseVersion(s string) (*Version, error) {
+	m := versionRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil, fmt.Errorf("invalid semantic version %q", s)
+	}
+
+	v := &Version{Build: m[5]}
+	var err error
+	if v.Major, err = strconv.ParseUint(m[1], 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid semantic version %q: %w", s, err)
+	}
+	if v.Minor, err = strconv.ParseUint(m[2], 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid semantic version %q: %w", s, err)
+	}
+	if v.Patch, err = strconv.ParseUint(m[3], 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid semantic version %q: %w", s, err)
+	}
+	if m[4] != "" {
+		v.Prerelease = strings.Split(m[4], ".")
+	}
+	return v, nil
+}
+
+// String formats the version in canonical form, without a leading "v".
+func (v *Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 according to semver precedence. Build
+// metadata does not affect precedence.
+func (v *Version) Compare(o *Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// compareTotal orders versions by precedence and then by build metadata,
+// so that sorting is deterministic for versions of equal precedence.
+func (v *Version) compareTotal(o *Version) int {
+	if c := v.Compare(o); c != 0 {
+		return c
+	}
+	return strings.Compare(v.Build, o.Build)
+}
+
+func (v *Version) sameTuple(o *Version) bool {
+	return v.Major == o.Major && v.Minor == o.Minor && v.Patch == o.Patch
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func comparePrerelease(a, b []string) int {
+	// A version without a pre-release has higher precedence.
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		an, aErr := strconv.ParseUint(a[i], 10, 64)
+		bn, bErr := strconv.ParseUint(b[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareUint(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareUint(uint64(len(a)), uint64(len(b)))
+}
+
+// SortVersions sorts version strings in ascending semver order. Strings
+// that are not valid versions sort first, in lexical order.
+func SortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, aErr := ParseVersion(versions[i])
+		b, bErr := ParseVersion(versions[j])
+		switch {
+		case aErr != nil && bErr != nil:
+			return versions[i] < versions[j]
+		case aErr != nil:
+			return true
+		case bErr != nil:
+			return false
+		}
+		return a.compareTotal(b) < 0
+	})
+}
+
+type comparator struct {
+	op string
+	v  *Version
+}
+
+func (c comparator) check(v *Version) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// Constraint is a parsed version range. The syntax follows npm: caret
+// (^1.2.3), tilde (~1.2.3), x-ranges (1.x, 1.2.*), hyphen ranges
+// (1.2.3 - 2.0.0), comparison operators (>=, >, <=, <, =) combined with
+// spaces or commas, and unions separated by "||". "*", "latest" and
+// "x" match any release.
+type Constraint struct {
+	raw  string
+	sets [][]comparator
+}
+
+// ParseConstraint parses a version range.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: strings.TrimSpace(s)}
+	for _, part := range strings.Split(c.raw, "||") {
+		set, err := parseComparatorSet(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		c.sets = append(c.sets, set)
+	}
+	return c, nil
+}
+
+// String returns the constraint as it was written.
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Check reports whether v satisfies the constraint. A pre-release version
+// only matches a comparator set that names a pre-release of the same
+// major.minor.patch, so ^1.0.0 never selects 1.1.0-beta.
+func (c *Constraint) Check(v *Version) bool {
+	for _, set := range c.sets {
+		if checkSet(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkSet(set []comparator, v *Version) bool {
+	for _, cmp := range set {
+		if !cmp.check(v) {
+			return false
+		}
+	}
+	if len(v.Prerelease) == 0 {
+		return true
+	}
+	for _, cmp := range set {
+		if len(cmp.v.Prerelease) > 0 && !isFloor(cmp.v) && cmp.v.sameTuple(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFloor reports whether v is a synthesized "-0" bound such as the
+// upper limit of ^1.2.3 (<2.0.0-0). Such bounds never admit pre-releases.
+func isFloor(v *Version) bool {
+	return len(v.Prerelease) == 1 && v.Prerelease[0] == "0"
+}
+
+// anchor returns the version a constraint is centred on, used to pick the
+// nearest candidates when nothing matches.
+func (c *Constraint) anchor() *Version {
+	for _, set := range c.sets {
+		for _, cmp := range set {
+			if cmp.op != "<" && cmp.op != "<=" {
+				return cmp.v
+			}
+		}
+		if len(set) > 0 {
+			return set[0].v
+		}
+	}
+	return &Version{}
+}
+
+var operatorRe = regexp.MustCompile(`^(<=|>=|<|>|=|~>|~|\^)?\s*(.*)$`)
+
+func parseComparatorSet(s string) ([]comparator, error) {
+	if s == "" || s == "*" || s == "latest" || s == "x" || s == "X" {
+		return []comparator{{op: ">=", v: &Version{}}}, nil
+	}
+
+	if parts := strings.Split(s, " - "); len(parts) == 2 {
+		return parseHyphenRange(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	} else if len(parts) > 2 {
+		return nil, fmt.Errorf("malformed hyphen range %q", s)
+	}
+
+	var set []comparator
+	for _, tok := range splitComparators(s) {
+		m := operatorRe.FindStringSubmatch(tok)
+		op, rest := m[1], m[2]
+		p, err := parsePartial(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		var cmps []comparator
+		switch op {
+		case "^":
+			cmps = caretRange(p)
+		case "~", "~>":
+			cmps = tildeRange(p)
+		case "", "=":
+			cmps = xRange(p)
+		default:
+			cmps = operatorRange(op, p)
+		}
+		set = append(set, cmps...)
+	}
+	return set, nil
+}
+
+// splitComparators splits a comparator set on whitespace and commas,
+// joining operators that are separated from their version ("> 1.2.3").
+func splitComparators(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ','
+	})
+
+	var toks []string
+	for i := 0; i < len(fields); i++ {
+		tok := fields[i]
+		if operatorRe.FindStringSubmatch(tok)[2] == "" && i+1 < len(fields) {
+			i++
+			tok += fields[i]
+		}
+		toks = append(toks, tok)
+	}
+	return toks
+}
+
+// partial is a possibly incomplete version such as "1", "1.2" or "1.x".
+// Missing or wildcard components are recorded as -1.
+type partial struct {
+	major, minor, patch int64
+	prerelease          []string
+	build               string
+}
+
+var partialRe = regexp.MustCompile(`^v?(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?` +
+	`(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+func parsePartial(s string) (partial, error) {
+	m := partialRe.FindStringSubmatch(s)
+	if m == nil {
+		return partial{}, fmt.Errorf("invalid version %q", s)
+	}
+
+	p := partial{major: -1, minor: -1, patch: -1, build: m[5]}
+	for i, dst := range []*int64{&p.major, &p.minor, &p.patch} {
+		field := m[i+1]
+		if field == "" || field == "x" || field == "X" || field == "*" {
+			continue
+		}
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return partial{}, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+		*dst = n
+	}
+	if p.major < 0 && (p.minor >= 0 || p.patch >= 0) || p.minor < 0 && p.patch >= 0 {
+		return partial{}, fmt.Errorf("invalid version %q: wildcard followed by a number", s)
+	}
+	if m[4] != "" {
+		if p.patch < 0 {
+			return partial{}, fmt.Errorf("invalid version %q: pre-release requires a full version", s)
+		}
+		p.prerelease = strings.Split(m[4], ".")
+	}
+	return p, nil
+}
+
+func (p partial) full() bool {
+	return p.patch >= 0
+}
+
+// floor returns the lowest version matched by p.
+func (p partial) floor() *Version {
+	v := &Version{Prerelease: p.prerelease, Build: p.build}
+	if p.major > 0 {
+		v.Major = uint64(p.major)
+	}
+	if p.minor > 0 {
+		v.Minor = uint64(p.minor)
+	}
+	if p.patch > 0 {
+		v.Patch = uint64(p.patch)
+	}
+	return v
+}
+
+// ceiling returns the exclusive upper bound of the versions matched by a
+// partial, e.g. 1.2 -> 1.3.0-0 and 1 -> 2.0.0-0. It returns nil when p is
+// a bare wildcard.
+func (p partial) ceiling() *Version {
+	switch {
+	case p.major < 0:
+		return nil
+	case p.minor < 0:
+		return bound(p.major+1, 0, 0)
+	case p.patch < 0:
+		return bound(p.major, p.minor+1, 0)
+	}
+	return bound(p.major, p.minor, p.patch+1)
+}
+
+func bound(major, minor, patch int64) *Version {
+	return &Version{Major: uint64(major), Minor: uint64(minor), Patch: uint64(patch), Prerelease: []string{"0"}}
+}
+
+func anyVersion() []comparator {
+	return []comparator{{op: ">=", v: &Version{}}}
+}
+
+func xRange(p partial) []comparator {
+	if p.major < 0 {
+		return anyVersion()
+	}
+	if p.full() {
+		return []comparator{{op: "=", v: p.floor()}}
+	}
+	return []comparator{{op: ">=", v: p.floor()}, {op: "<", v: p.ceiling()}}
+}
+
+func tildeRange(p partial) []comparator {
+	switch {
+	case p.major < 0:
+		return anyVersion()
+	case p.minor < 0:
+		return []comparator{{op: ">=", v: p.floor()}, {op: "<", v: bound(p.major+1, 0, 0)}}
+	}
+	return []comparator{{op: ">=", v: p.floor()}, {op: "<", v: bound(p.major, p.minor+1, 0)}}
+}
+
+func caretRange(p partial) []comparator {
+	var upper *Version
+	switch {
+	case p.major < 0:
+		return anyVersion()
+	case p.major > 0 || p.minor < 0:
+		upper = bound(p.major+1, 0, 0)
+	case p.minor > 0 || p.patch < 0:
+		upper = bound(0, p.minor+1, 0)
+	default:
+		upper = bound(0, 0, p.patch+1)
+	}
+	return []comparator{{op: ">=", v: p.floor()}, {op: "<", v: upper}}
+}
+
+func operatorRange(op string, p partial) []comparator {
+	if p.major < 0 {
+		if op == "<" || op == ">" {
+			// Nothing is below or above every version.
+			return []comparator{{op: "<", v: &Version{Prerelease: []string{"0"}}}}
+		}
+		return anyVersion()
+	}
+	if p.full() {
+		return []comparator{{op: op, v: p.floor()}}
+	}
+
+	switch op {
+	case ">":
+		return []comparator{{op: ">=", v: p.ceiling()}}
+	case ">=":
+		return []comparator{{op: ">=", v: p.floor()}}
+	case "<":
+		floor := p.floor()
+		floor.Prerelease = []string{"0"}
+		return []comparator{{op: "<", v: floor}}
+	default: // "<="
+		return []comparator{{op: "<", v: p.ceiling()}}
+	}
+}
+
+func parseHyphenRange(from, to string) ([]comparator, error) {
+	lo, err := parsePartial(from)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := parsePartial(to)
+	if err != nil {
+		return nil, err
+	}
+
+	set := []comparator{{op: ">=", v: lo.floor()}}
+	switch {
+	case hi.major < 0:
+	case hi.full():
+		set = append(set, comparator{op: "<=", v: hi.floor()})
+	default:
+		set = append(set, comparator{op: "<", v: hi.ceiling()})
+	}
+	return set, nil
+}
+
+// VersionError reports that no available version satisfies a constraint.
+// Nearest holds the available versions closest to the requested range.
+type VersionError struct {
+	Constraint string
+	Nearest    []string
+}
+
+func (e *VersionError) Error() string {
+	if len(e.Nearest) == 0 {
+		return fmt.Sprintf("no version satisfies %q", e.Constraint)
+	}
+	return fmt.Sprintf("no version satisfies %q (nearest candidates: %s)", e.Constraint, strings.Join(e.Nearest, ", "))
+}
+
+// nearestVersions returns up to two versions on either side of the
+// constraint's anchor. versions must be sorted in ascending order.
+func nearestVersions(c *Constraint, versions []*Version) []string {
+	anchor := c.anchor()
+	i := sort.Search(len(versions), func(i int) bool {
+		return versions[i].Compare(anchor) >= 0
+	})
+
+	lo, hi := i-2, i+2
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(versions) {
+		hi = len(versions)
+	}
+
+	var nearest []string
+	for _, v := range versions[lo:hi] {
+		nearest = append(nearest, v.String())
+	}
+	return nearest
+}
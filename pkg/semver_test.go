@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("v1.2.3-beta.1+build.5")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), v.Major)
+	assert.Equal(t, uint64(2), v.Minor)
+	assert.Equal(t, uint64(3), v.Patch)
+	assert.Equal(t, []string{"beta", "1"}, v.Prerelease)
+	assert.Equal(t, "build.5", v.Build)
+	assert.Equal(t, "1.2.3-beta.1+build.5", v.String())
+
+	for _, invalid := range []string{"", "1", "1.2", "01.2.3", "1.2.3-", "1.2.3-01", "1.2.3+", "a.b.c"} {
+		_, err := ParseVersion(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestVersionPrecedence(t *testing.T) {
+	// Ordered list from the semver specification.
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "2.0.0", "2.1.0", "2.1.1",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, _ := ParseVersion(ordered[i])
+		b, _ := ParseVersion(ordered[i+1])
+		assert.Equal(t, -1, a.Compare(b), "%s < %s", ordered[i], ordered[i+1])
+		assert.Equal(t, 1, b.Compare(a), "%s > %s", ordered[i+1], ordered[i])
+	}
+
+	a, _ := ParseVersion("1.0.0+build.1")
+	b, _ := ParseVersion("1.0.0+build.2")
+	assert.Equal(t, 0, a.Compare(b), "build metadata does not affect precedence")
+}
+
+func TestSortVersions(t *testing.T) {
+	versions := []string{"2.0.0", "1.0.0+b", "not-a-version", "1.0.0-rc.1", "1.0.0+a", "0.9.0"}
+	SortVersions(versions)
+	assert.Equal(t, []string{"not-a-version", "0.9.0", "1.0.0-rc.1", "1.0.0+a", "1.0.0+b", "2.0.0"}, versions)
+}
+
+func TestConstraintCheck(t *testing.T) {
+	testCases := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{"^1.2.3", []string{"1.2.3", "1.9.9"}, []string{"1.2.2", "2.0.0", "2.0.0-0", "1.3.0-beta"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^1.x", []string{"1.0.0", "1.5.0"}, []string{"2.0.0"}},
+		{"^0.x", []string{"0.0.1", "0.9.0"}, []string{"1.0.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"}},
+		{"~1.2", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"~>1.2", []string{"1.2.5"}, []string{"1.3.0"}},
+		{"1.x", []string{"1.0.0", "1.9.9"}, []string{"2.0.0", "0.9.0"}},
+		{"1.2.*", []string{"1.2.0", "1.2.7"}, []string{"1.3.0"}},
+		{"1", []string{"1.4.0"}, []string{"2.0.0"}},
+		{"*", []string{"0.0.1", "9.9.9"}, []string{"1.0.0-beta"}},
+		{"latest", []string{"3.0.0"}, []string{"3.1.0-rc.1"}},
+		{"", []string{"3.0.0"}, nil},
+		{"1.2.3", []string{"1.2.3", "1.2.3+build"}, []string{"1.2.4"}},
+		{"=1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"1.2.3 - 2.3.4", []string{"1.2.3", "2.3.4"}, []string{"2.3.5", "1.2.2"}},
+		{"1.2 - 2.3", []string{"1.2.0", "2.3.9"}, []string{"2.4.0"}},
+		{"1.2.3 - 2", []string{"2.9.9"}, []string{"3.0.0"}},
+		{">=1.2.3 <2.0.0", []string{"1.2.3", "1.99.0"}, []string{"2.0.0", "1.2.2"}},
+		{">= 1.2.3, < 2.0.0", []string{"1.5.0"}, []string{"2.0.0"}},
+		{">1.2.3", []string{"1.2.4"}, []string{"1.2.3"}},
+		{"<=1.2.3", []string{"1.2.3", "0.1.0"}, []string{"1.2.4"}},
+		{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{"<1.2", []string{"1.1.9"}, []string{"1.2.0"}},
+		{"<=1.2", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"^1.0.0 || ^3.0.0", []string{"1.5.0", "3.2.0"}, []string{"2.0.0"}},
+		{"<1.0.0 || >=2.0.0 <2.1.0", []string{"0.5.0", "2.0.5"}, []string{"1.0.0", "2.1.0"}},
+		{">=1.2.3-beta.2", []string{"1.2.3-beta.2", "1.2.3-rc.1", "1.2.3", "1.3.0"}, []string{"1.2.3-beta.1", "1.3.0-beta"}},
+		{"^1.2.3-beta.2", []string{"1.2.3-beta.4", "1.5.0"}, []string{"1.2.4-beta.1", "1.2.3-beta.1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.constraint, func(t *testing.T) {
+			c, err := ParseConstraint(tc.constraint)
+			require.NoError(t, err)
+			for _, s := range tc.matches {
+				v, err := ParseVersion(s)
+				require.NoError(t, err)
+				assert.True(t, c.Check(v), "%s should satisfy %s", s, tc.constraint)
+			}
+			for _, s := range tc.rejects {
+				v, err := ParseVersion(s)
+				require.NoError(t, err)
+				assert.False(t, c.Check(v), "%s should not satisfy %s", s, tc.constraint)
+			}
+		})
+	}
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, invalid := range []string{"^", ">=abc", "1.2.3.4", "x.1", "1.2 - 2.0 - 3.0", "^1.2-beta"} {
+		_, err := ParseConstraint(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestResolveVersionSelectsHighest(t *testing.T) {
+	available := []string{"0.3.0", "1.0.0", "1.4.2", "1.10.0", "2.0.0", "1.11.0-beta.1"}
+
+	testCases := []struct {
+		required string
+		want     string
+	}{
+		{"^1.0.0", "1.10.0"},
+		{"~1.4.0", "1.4.2"},
+		{"*", "2.0.0"},
+		{"<1.0.0", "0.3.0"},
+		{"^1.11.0-beta.0", "1.11.0-beta.1"},
+		{"0.3.0 || 1.4.x", "1.4.2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.required, func(t *testing.T) {
+			got, err := ResolveVersion(tc.required, available)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PackageSource provides the metadata the dependency solver needs about
+// published packages. Registries implement it.
+type PackageSource interface {
+	// Versions lists every published version of a package.
+	Versions(name string) ([]string, error)
+	// Dependencies returns the dependency ranges declared by one version.
+	Dependencies(name, version string) (map[string]string, error)
+}
+
+// Resolved is a package version selected by the solver.
+type Resolved struct {
+	Name         string
+	Version      string
+	Dependencies map[string]string
+}
+
+// Requirement is a version range placed on a package, together with the
+// chain of selected packages that introduced it. Path starts with the root
+// package name; later entries have the form name@version.
+type Requirement struct {
+	Path       []string
+	Constraint string
+}
+
+// ConflictError explains why no set of versions satisfies every
+// requirement on a package.
+type ConflictError struct {
+	Package      string
+	Requirements []Requirement
+	// Available lists the published versions of Package.
+	Available []string
+	// Tried records the other package versions the solver backtracked
+	// over before giving up, as name@version.
+	Tried []string
+}
+
+func (e *ConflictError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "dependency resolution failed: no version of %s satisfies every requirement", e.Package)
+	for _, req := range e.Requirements {
+		fmt.Fprintf(&b, "\n  %s requires %s %s", strings.Join(req.Path, " -> "), e.Package, req.Constraint)
+	}
+	if len(e.Available) > 0 {
+		fmt.Fprintf(&b, "\n  available versions of %s: %s", e.Package, strings.Join(e.Available, ", "))
+	} else {
+		fmt.Fprintf(&b, "\n  %s has no published versions", e.Package)
+	}
+	if len(e.Tried) > 0 {
+		fmt.Fprintf(&b, "\n  also tried: %s", strings.Join(e.Tried, ", "))
+	}
+	return b.String()
+}
+
+// Solver selects one version of every package reachable from a manifest so
+// that all dependency ranges are satisfied. It searches depth first,
+// deciding the most constrained package next and backtracking when a choice
+// leads to a conflict.
+type Solver struct {
+	Source PackageSource
+	// Preferred maps package names to versions that should be tried first
+	// when they satisfy the requirements, such as those in a lockfile.
+	Preferred map[string]string
+}
+
+// Solve resolves the transitive dependencies of root using src.
+func Solve(root *AgentPkg, src PackageSource) ([]Resolved, error) {
+	solver := &Solver{Source: src}
+	return solver.Solve(root)
+}
+
+// Solve resolves the transitive dependencies of root, including its
+// development dependencies. The result is sorted by package name and does
+// not include root itself.
+func (s *Solver) Solve(root *AgentPkg) ([]Resolved, error) {
+	if root == nil {
+		return nil, fmt.Errorf("agentPkg cannot be nil")
+	}
+	if s.Source == nil {
+		return nil, fmt.Errorf("solver has no package source")
+	}
+
+	st := &solveState{
+		solver:   s,
+		selected: make(map[string]*Resolved),
+		reqs:     make(map[string][]requirement),
+		versions: make(map[string][]*Version),
+	}
+	rootName := root.Name
+	if rootName == "" {
+		rootName = "root"
+	}
+	if _, err := st.require([]string{rootName}, root.AllDependencies()); err != nil {
+		return nil, err
+	}
+	if err := st.solve(); err != nil {
+		return nil, err
+	}
+
+	resolved := make([]Resolved, 0, len(st.selected))
+	for _, r := range st.selected {
+		resolved = append(resolved, *r)
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].Name < resolved[j].Name
+	})
+	return resolved, nil
+}
+
+type requirement struct {
+	Requirement
+	constraint *Constraint
+}
+
+type solveState struct {
+	solver   *Solver
+	selected map[string]*Resolved
+	reqs     map[string][]requirement
+	versions map[string][]*Version
+}
+
+// require records the dependencies of the package at the end of path. It
+// returns the names whose requirement lists grew, so they can be undone.
+func (st *solveState) require(path []string, deps map[string]string) ([]string, error) {
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		c, err := ParseConstraint(deps[name])
+		if err != nil {
+			st.unrequire(names[:i])
+			return nil, fmt.Errorf("%s: dependency %s: %w", strings.Join(path, " -> "), name, err)
+		}
+		st.reqs[name] = append(st.reqs[name], requirement{
+			Requirement: Requirement{Path: path, Constraint: deps[name]},
+			constraint:  c,
+		})
+	}
+	return names, nil
+}
+
+func (st *solveState) unrequire(names []string) {
+	for _, name := range names {
+		reqs := st.reqs[name]
+		if len(reqs) <= 1 {
+			delete(st.reqs, name)
+			continue
+		}
+		st.reqs[name] = reqs[:len(reqs)-1]
+	}
+}
+
+func (st *solveState) solve() error {
+	name, candidates, err := st.next()
+	if err != nil || name == "" {
+		return err
+	}
+	if len(candidates) == 0 {
+		return st.conflict(name)
+	}
+
+	var firstConflict *ConflictError
+	for _, v := range candidates {
+		version := v.String()
+		deps, err := st.solver.Source.Dependencies(name, version)
+		if err != nil {
+			return fmt.Errorf("failed to read dependencies of %s@%s: %w", name, version, err)
+		}
+
+		path := append(append([]string{}, st.reqs[name][0].Path...), name+"@"+version)
+		st.selected[name] = &Resolved{Name: name, Version: version, Dependencies: deps}
+		added, err := st.require(path, deps)
+		if err != nil {
+			delete(st.selected, name)
+			return err
+		}
+
+		err = st.check(added)
+		if err == nil {
+			err = st.solve()
+		}
+		if err == nil {
+			return nil
+		}
+
+		st.unrequire(added)
+		delete(st.selected, name)
+
+		conflict, ok := err.(*ConflictError)
+		if !ok {
+			return err
+		}
+		if firstConflict == nil {
+			firstConflict = conflict
+		} else {
+			firstConflict.Tried = append(firstConflict.Tried, name+"@"+version)
+		}
+	}
+	return firstConflict
+}
+
+// check verifies that already selected packages still satisfy the
+// requirements just added to them.
+func (st *solveState) check(names []string) error {
+	for _, name := range names {
+		sel, ok := st.selected[name]
+		if !ok {
+			continue
+		}
+		v, err := ParseVersion(sel.Version)
+		if err != nil {
+			return err
+		}
+		reqs := st.reqs[name]
+		if !reqs[len(reqs)-1].constraint.Check(v) {
+			return st.conflict(name)
+		}
+	}
+	return nil
+}
+
+// next picks the undecided package with the fewest candidate versions,
+// breaking ties by name, and returns its candidates best first.
+func (st *solveState) next() (string, []*Version, error) {
+	names := make([]string, 0, len(st.reqs))
+	for name := range st.reqs {
+		if _, ok := st.selected[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	best := ""
+	var bestCandidates []*Version
+	for _, name := range names {
+		candidates, err := st.candidates(name)
+		if err != nil {
+			return "", nil, err
+		}
+		if best == "" || len(candidates) < len(bestCandidates) {
+			best, bestCandidates = name, candidates
+		}
+		if len(candidates) == 0 {
+			break
+		}
+	}
+	return best, bestCandidates, nil
+}
+
+func (st *solveState) candidates(name string) ([]*Version, error) {
+	versions, err := st.available(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*Version
+	preferred := st.solver.Preferred[name]
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		if !st.satisfies(name, v) {
+			continue
+		}
+		if preferred != "" && v.String() == preferred {
+			candidates = append([]*Version{v}, candidates...)
+			continue
+		}
+		candidates = append(candidates, v)
+	}
+	return candidates, nil
+}
+
+func (st *solveState) satisfies(name string, v *Version) bool {
+	for _, req := range st.reqs[name] {
+		if !req.constraint.Check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// available returns the published versions of name in ascending order.
+func (st *solveState) available(name string) ([]*Version, error) {
+	if versions, ok := st.versions[name]; ok {
+		return versions, nil
+	}
+
+	list, err := st.solver.Source.Versions(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s requires %s: %w", strings.Join(st.reqs[name][0].Path, " -> "), name, err)
+	}
+	versions := make([]*Version, 0, len(list))
+	for _, s := range list {
+		if v, err := ParseVersion(s); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].compareTotal(versions[j]) < 0
+	})
+	st.versions[name] = versions
+	return versions, nil
+}
+
+func (st *solveState) conflict(name string) *ConflictError {
+	e := &ConflictError{Package: name}
+	for _, req := range st.reqs[name] {
+		e.Requirements = append(e.Requirements, req.Requirement)
+	}
+	for _, v := range st.versions[name] {
+		e.Available = append(e.Available, v.String())
+	}
+	return e
+}
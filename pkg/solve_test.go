@@ -0,0 +1,169 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySource is an in-memory PackageSource keyed by name and version.
+type memorySource map[string]map[string]map[string]string
+
+func (m memorySource) Versions(name string) ([]string, error) {
+	versions, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("package %s not found", name)
+	}
+	var list []string
+	for v := range versions {
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+func (m memorySource) Dependencies(name, version string) (map[string]string, error) {
+	return m[name][version], nil
+}
+
+func resolvedVersions(resolved []Resolved) map[string]string {
+	versions := make(map[string]string)
+	for _, r := range resolved {
+		versions[r.Name] = r.Version
+	}
+	return versions
+}
+
+func TestSolveTransitive(t *testing.T) {
+	src := memorySource{
+		"research-agent": {
+			"1.0.0": {"web-search": "^2.0.0", "summarize-chain": "~1.1.0"},
+			"1.1.0": {"web-search": "^2.1.0", "summarize-chain": "~1.1.0"},
+		},
+		"web-search":      {"2.0.0": nil, "2.1.0": nil, "2.2.0": nil, "3.0.0": nil},
+		"summarize-chain": {"1.1.0": {"summary-prompts": "1.x"}, "1.1.4": {"summary-prompts": "1.x"}, "1.2.0": nil},
+		"summary-prompts": {"1.0.0": nil, "1.3.0": nil, "2.0.0": nil},
+	}
+	root := &AgentPkg{Name: "my-app", Dependencies: map[string]string{"research-agent": "^1.0.0"}}
+
+	resolved, err := Solve(root, src)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"research-agent":  "1.1.0",
+		"web-search":      "2.2.0",
+		"summarize-chain": "1.1.4",
+		"summary-prompts": "1.3.0",
+	}, resolvedVersions(resolved))
+	assert.Equal(t, "research-agent", resolved[0].Name, "result is sorted by name")
+}
+
+func TestSolveDevDependencies(t *testing.T) {
+	src := memorySource{
+		"web-search": {"2.0.0": {"http-tool": "^1.0.0"}},
+		"http-tool":  {"1.0.0": nil, "1.1.0": nil},
+		"eval-kit":   {"0.3.0": {"http-tool": "~1.0.0"}},
+	}
+	root := &AgentPkg{
+		Name:            "my-app",
+		Dependencies:    map[string]string{"web-search": "^2.0.0"},
+		DevDependencies: map[string]string{"eval-kit": "^0.3.0"},
+	}
+
+	resolved, err := Solve(root, src)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"web-search": "2.0.0",
+		"eval-kit":   "0.3.0",
+		"http-tool":  "1.0.0",
+	}, resolvedVersions(resolved))
+}
+
+func TestSolveBacktracks(t *testing.T) {
+	// The newest tool-a needs lib 2.x, which conflicts with the root, so the
+	// solver has to fall back to tool-a 1.0.0.
+	src := memorySource{
+		"tool-a": {"1.0.0": {"lib": "^1.0.0"}, "1.1.0": {"lib": "^2.0.0"}},
+		"lib":    {"1.0.0": nil, "1.5.0": nil, "2.0.0": nil},
+	}
+	root := &AgentPkg{Name: "my-app", Dependencies: map[string]string{"tool-a": "^1.0.0", "lib": "<2.0.0"}}
+
+	resolved, err := Solve(root, src)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"tool-a": "1.0.0", "lib": "1.5.0"}, resolvedVersions(resolved))
+}
+
+func TestSolvePreferred(t *testing.T) {
+	src := memorySource{"lib": {"1.0.0": nil, "1.1.0": nil, "1.2.0": nil}}
+	root := &AgentPkg{Name: "my-app", Dependencies: map[string]string{"lib": "^1.0.0"}}
+
+	solver := &Solver{Source: src, Preferred: map[string]string{"lib": "1.1.0"}}
+	resolved, err := solver.Solve(root)
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", resolved[0].Version)
+
+	solver.Preferred["lib"] = "0.9.0"
+	resolved, err = solver.Solve(root)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", resolved[0].Version, "preferred versions outside the range are ignored")
+}
+
+func TestSolveCycle(t *testing.T) {
+	src := memorySource{
+		"a": {"1.0.0": {"b": "^1.0.0"}},
+		"b": {"1.0.0": {"a": "^1.0.0"}},
+	}
+	root := &AgentPkg{Name: "my-app", Dependencies: map[string]string{"a": "*"}}
+
+	resolved, err := Solve(root, src)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1.0.0", "b": "1.0.0"}, resolvedVersions(resolved))
+}
+
+func TestSolveConflictExplanation(t *testing.T) {
+	src := memorySource{
+		"tool-a": {"1.0.0": {"lib": "^2.0.0"}, "1.1.0": {"lib": "^2.1.0"}},
+		"lib":    {"1.0.0": nil, "2.0.0": nil, "2.1.0": nil},
+	}
+	root := &AgentPkg{Name: "my-app", Dependencies: map[string]string{"tool-a": "^1.0.0", "lib": "^1.0.0"}}
+
+	_, err := Solve(root, src)
+	require.Error(t, err)
+
+	var conflict *ConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, "lib", conflict.Package)
+	assert.Equal(t, []string{"tool-a@1.0.0"}, conflict.Tried)
+
+	msg := err.Error()
+	assert.Contains(t, msg, "no version of lib satisfies every requirement")
+	assert.Contains(t, msg, "my-app requires lib ^1.0.0")
+	assert.Contains(t, msg, "my-app -> tool-a@1.1.0 requires lib ^2.1.0")
+	assert.Contains(t, msg, "available versions of lib: 1.0.0, 2.0.0, 2.1.0")
+}
+
+func TestSolveNoMatchingVersion(t *testing.T) {
+	src := memorySource{"lib": {"1.0.0": nil}}
+	root := &AgentPkg{Name: "my-app", Dependencies: map[string]string{"lib": "^3.0.0"}}
+
+	_, err := Solve(root, src)
+	var conflict *ConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, []string{"1.0.0"}, conflict.Available)
+}
+
+func TestSolveErrors(t *testing.T) {
+	src := memorySource{"a": {"1.0.0": {"b": "not a range"}}}
+
+	_, err := Solve(&AgentPkg{Name: "my-app", Dependencies: map[string]string{"missing": "^1.0.0"}}, src)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "my-app requires missing")
+
+	_, err = Solve(&AgentPkg{Name: "my-app", Dependencies: map[string]string{"a": "^1.0.0"}}, src)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "my-app -> a@1.0.0: dependency b")
+
+	_, err = Solve(nil, src)
+	assert.Error(t, err)
+}
@@ -0,0 +1,372 @@
+package pkg
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity says whether a problem makes a manifest invalid.
+type Severity string
+
+const (
+	// SeverityError problems make the package invalid.
+	SeverityError Severity = "error"
+	// SeverityWarning problems are reported but do not stop a build.
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is one issue found in a manifest. Field is the path of the
+// offending field, such as "dependencies.web-search" or
+// "chain.steps[1].id". File, Line and Column locate it in the source and
+// are empty when the manifest was not read from a file; Line and Column
+// are 1-based.
+type Problem struct {
+	Field    string   `json:"field"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+
+	path  []string
+	atKey bool
+}
+
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.File != "" {
+		b.WriteString(p.File)
+		if p.Line > 0 {
+			fmt.Fprintf(&b, ":%d", p.Line)
+			if p.Column > 0 {
+				fmt.Fprintf(&b, ":%d", p.Column)
+			}
+		}
+		b.WriteString(": ")
+	}
+	fmt.Fprintf(&b, "%s: ", p.Severity)
+	if p.Field != "" {
+		fmt.Fprintf(&b, "%s: ", p.Field)
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// ValidationError reports every problem found in an invalid manifest,
+// including its warnings.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	errs := 0
+	for _, p := range e.Problems {
+		if p.Severity == SeverityError {
+			errs++
+		}
+	}
+	noun := "errors"
+	if errs == 1 {
+		noun = "error"
+	}
+	lines := []string{fmt.Sprintf("invalid manifest: %d %s", errs, noun)}
+	for _, p := range e.Problems {
+		lines = append(lines, "  "+p.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// HasErrors reports whether any of problems is an error.
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// checker collects the problems found while validating a manifest.
+type checker struct {
+	problems []Problem
+}
+
+func (c *checker) add(severity Severity, atKey bool, path []string, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{
+		Field:    fieldName(path),
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		path:     path,
+		atKey:    atKey,
+	})
+}
+
+func (c *checker) errorf(path []string, format string, args ...interface{}) {
+	c.add(SeverityError, false, path, format, args...)
+}
+
+func (c *checker) warnf(path []string, format string, args ...interface{}) {
+	c.add(SeverityWarning, false, path, format, args...)
+}
+
+// keyErrorf reports a problem with a mapping key rather than its value,
+// such as an invalid dependency name.
+func (c *checker) keyErrorf(path []string, format string, args ...interface{}) {
+	c.add(SeverityError, true, path, format, args...)
+}
+
+// fieldPath returns the path of a field below base. Sequence indexes are
+// written as "[i]".
+func fieldPath(base []string, elems ...interface{}) []string {
+	p := append([]string(nil), base...)
+	for _, e := range elems {
+		switch e := e.(type) {
+		case int:
+			p = append(p, "["+strconv.Itoa(e)+"]")
+		default:
+			p = append(p, fmt.Sprint(e))
+		}
+	}
+	return p
+}
+
+func fieldName(path []string) string {
+	var b strings.Builder
+	for i, seg := range path {
+		if i > 0 && !strings.HasPrefix(seg, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(seg)
+	}
+	return b.String()
+}
+
+// ReservedNames cannot be used as package names.
+var ReservedNames = []string{
+	"agenthub", "agent_modules", "node_modules", "favicon.ico",
+	"con", "prn", "aux", "nul",
+	"com1", "com2", "com3", "com4", "com5", "com6", "com7", "com8", "com9",
+	"lpt1", "lpt2", "lpt3", "lpt4", "lpt5", "lpt6", "lpt7", "lpt8", "lpt9",
+}
+
+// MaxNameLength is the longest allowed package name, including its scope.
+const MaxNameLength = 214
+
+// CheckAgentPkg returns every problem found in agentPkg, errors and
+// warnings alike.
+func CheckAgentPkg(agentPkg *AgentPkg) []Problem {
+	c := &checker{}
+	if agentPkg == nil {
+		c.errorf(nil, "agentPkg cannot be nil")
+		return c.problems
+	}
+
+	checkPackageName(c, []string{"name"}, agentPkg.Name, false)
+
+	if agentPkg.Version == "" {
+		c.errorf([]string{"version"}, "version is required")
+	} else if _, err := ParseVersion(agentPkg.Version); err != nil {
+		c.errorf([]string{"version"}, "%v", err)
+	}
+
+	if strings.TrimSpace(agentPkg.Description) == "" {
+		c.warnf([]string{"description"}, "a description helps users find the package")
+	}
+	checkAuthor(c, agentPkg.Author)
+	checkLicense(c, agentPkg.License)
+
+	checkDependencies(c, "dependencies", agentPkg.Name, agentPkg.Dependencies)
+	checkDependencies(c, "devDependencies", agentPkg.Name, agentPkg.DevDependencies)
+	for _, name := range sortedKeys(agentPkg.DevDependencies) {
+		if _, ok := agentPkg.Dependencies[name]; ok {
+			c.keyErrorf(fieldPath([]string{"devDependencies"}, name), "%s is also listed in dependencies", name)
+		}
+	}
+
+	checkKind(c, agentPkg)
+	return c.problems
+}
+
+// ValidateAgentPkg validates an agent package configuration. When it has
+// errors, the result is a *ValidationError listing every problem.
+func ValidateAgentPkg(agentPkg *AgentPkg) error {
+	problems := CheckAgentPkg(agentPkg)
+	if HasErrors(problems) {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidateManifest loads the manifest at filename and checks it, returning
+// the package and every problem found, located in the file. The error is
+// only set when the manifest cannot be read or decoded.
+func ValidateManifest(filename string) (*AgentPkg, []Problem, error) {
+	return validate(filename, nil, false)
+}
+
+// ValidatePackage is ValidateManifest followed by CheckContents, for the
+// package whose manifest is at filename and whose archive would hold
+// files.
+func ValidatePackage(filename string, files []string) (*AgentPkg, []Problem, error) {
+	return validate(filename, files, true)
+}
+
+func validate(filename string, files []string, contents bool) (*AgentPkg, []Problem, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	agentPkg, err := ParseAgentPkg(filename, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	problems := CheckAgentPkg(agentPkg)
+	if contents {
+		problems = append(problems, CheckContents(filepath.Dir(filename), files, agentPkg)...)
+	}
+	LocateProblems(filename, data, problems)
+	return agentPkg, problems, nil
+}
+
+// LocateProblems sets the File, Line and Column of each problem found in
+// the manifest data read from filename. Problems with a field missing from
+// the source are placed at the nearest enclosing field that exists.
+func LocateProblems(filename string, data []byte, problems []Problem) {
+	// JSON is close enough to YAML for yaml.v3 to parse it and report
+	// positions.
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		root = yaml.Node{}
+	}
+	for i := range problems {
+		problems[i].File = filename
+		if node := findNode(&root, problems[i].path, problems[i].atKey); node != nil {
+			problems[i].Line, problems[i].Column = node.Line, node.Column
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+}
+
+// findNode returns the node of the field at path in a decoded document,
+// or of the deepest enclosing field that exists. The top-level mapping is
+// never returned, since its position says nothing about the problem.
+func findNode(root *yaml.Node, path []string, atKey bool) *yaml.Node {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	var found *yaml.Node
+	for i, seg := range path {
+		last := i == len(path)-1
+		switch {
+		case strings.HasPrefix(seg, "[") && node.Kind == yaml.SequenceNode:
+			index, err := strconv.Atoi(strings.Trim(seg, "[]"))
+			if err != nil || index >= len(node.Content) {
+				return found
+			}
+			node = node.Content[index]
+			found = node
+		case node.Kind == yaml.MappingNode:
+			var next *yaml.Node
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if node.Content[j].Value == seg {
+					next = node.Content[j+1]
+					if last && atKey {
+						return node.Content[j]
+					}
+					break
+				}
+			}
+			if next == nil {
+				return found
+			}
+			node = next
+			found = node
+		default:
+			return found
+		}
+	}
+	return found
+}
+
+func checkDependencies(c *checker, section, self string, deps map[string]string) {
+	for _, name := range sortedKeys(deps) {
+		p := fieldPath([]string{section}, name)
+		checkPackageName(c, p, name, true)
+		if name == self {
+			c.keyErrorf(p, "a package cannot depend on itself")
+		}
+		constraint := deps[name]
+		if strings.TrimSpace(constraint) == "" {
+			c.warnf(p, "an empty constraint matches any version; write \"*\" to make that explicit")
+		} else if _, err := ParseConstraint(constraint); err != nil {
+			c.errorf(p, "%v", err)
+		}
+	}
+}
+
+func checkPackageName(c *checker, p []string, name string, isKey bool) {
+	report := c.errorf
+	if isKey {
+		report = c.keyErrorf
+	}
+	switch {
+	case name == "":
+		report(p, "name is required")
+	case len(name) > MaxNameLength:
+		report(p, "name is %d characters long; the limit is %d", len(name), MaxNameLength)
+	case strings.ToLower(name) != name:
+		report(p, "name %q must be lowercase", name)
+	case ValidateName(name) != nil:
+		report(p, "invalid package name %q: use lowercase letters, digits, \".\", \"_\" and \"-\", optionally scoped as @scope/name", name)
+	default:
+		base := name[strings.LastIndex(name, "/")+1:]
+		for _, reserved := range ReservedNames {
+			if base == reserved {
+				report(p, "name %q is reserved", name)
+			}
+		}
+	}
+}
+
+// authorPattern matches the "Name <email> (url)" author format, where each
+// part is optional.
+var authorPattern = regexp.MustCompile(`^([^<>()]*?)\s*(?:<([^<>]*)>)?\s*(?:\(([^()]*)\))?$`)
+
+func checkAuthor(c *checker, author string) {
+	author = strings.TrimSpace(author)
+	if author == "" {
+		return
+	}
+	p := []string{"author"}
+	m := authorPattern.FindStringSubmatch(author)
+	if m == nil {
+		c.errorf(p, "author %q must be formatted as \"Name <email> (url)\"", author)
+		return
+	}
+	name, email, site := m[1], m[2], m[3]
+	if name == "" {
+		c.warnf(p, "author has no name")
+	}
+	if email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			c.errorf(p, "invalid email address %q", email)
+		}
+	}
+	if site != "" {
+		if u, err := url.ParseRequestURI(site); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			c.errorf(p, "invalid URL %q: use an http or https URL", site)
+		}
+	}
+}
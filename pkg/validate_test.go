@@ -0,0 +1,202 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifest(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestValidateManifestReportsAllProblems(t *testing.T) {
+	path := writeManifest(t, "agentpkg.yaml", `name: My-Agent
+version: 1.0
+description: An agent
+author: Jane <not-an-email>
+license: MIT
+dependencies:
+  web-search: "^1.x.y"
+  Bad_Name: "*"
+`)
+
+	agentPkg, problems, err := ValidateManifest(path)
+	require.NoError(t, err)
+	require.NotNil(t, agentPkg)
+	assert.True(t, HasErrors(problems))
+
+	type located struct {
+		Field  string
+		Line   int
+		Column int
+	}
+	var got []located
+	for _, p := range problems {
+		assert.Equal(t, path, p.File)
+		assert.Equal(t, SeverityError, p.Severity)
+		got = append(got, located{p.Field, p.Line, p.Column})
+	}
+	assert.Equal(t, []located{
+		{"name", 1, 7},
+		{"version", 2, 10},
+		{"author", 4, 9},
+		{"dependencies.web-search", 7, 15},
+		{"dependencies.Bad_Name", 8, 3},
+	}, got)
+}
+
+func TestValidateManifestJSONPositions(t *testing.T) {
+	path := writeManifest(t, "agentpkg.json", `{
+  "name": "agent",
+  "version": "1.0.0",
+  "license": "MIT",
+  "kind": "chain",
+  "chain": {
+    "steps": [
+      {"id": "a", "prompt": "a.md"},
+      {"id": "a", "uses": "missing"}
+    ]
+  }
+}
+`)
+
+	_, problems, err := ValidateManifest(path)
+	require.NoError(t, err)
+	var fields []string
+	for _, p := range problems {
+		fields = append(fields, p.Field)
+		if p.Severity == SeverityError {
+			assert.Equal(t, 9, p.Line, p.String())
+		}
+	}
+	assert.Equal(t, []string{"description", "chain.steps[1].id", "chain.steps[1].uses"}, fields)
+}
+
+func TestValidateManifestWarningsOnly(t *testing.T) {
+	path := writeManifest(t, "agentpkg.yaml", "name: agent\nversion: 1.0.0\n")
+
+	_, problems, err := ValidateManifest(path)
+	require.NoError(t, err)
+	assert.False(t, HasErrors(problems))
+	require.Len(t, problems, 2)
+	assert.Equal(t, "description", problems[0].Field)
+	assert.Equal(t, "license", problems[1].Field)
+	assert.Equal(t, SeverityWarning, problems[1].Severity)
+}
+
+func TestValidationErrorListsProblems(t *testing.T) {
+	err := ValidateAgentPkg(&AgentPkg{Name: "agenthub", Version: "x"})
+	require.Error(t, err)
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Contains(t, err.Error(), "invalid manifest: 2 errors")
+	assert.Contains(t, err.Error(), `error: name: name "agenthub" is reserved`)
+	assert.Contains(t, err.Error(), "error: version: ")
+	assert.Contains(t, err.Error(), "warning: license: ")
+}
+
+func TestCheckPackageName(t *testing.T) {
+	tests := []struct {
+		name string
+		err  string
+	}{
+		{"agent", ""},
+		{"@acme/web-search", ""},
+		{"my.agent_2", ""},
+		{"", "name is required"},
+		{"Agent", "must be lowercase"},
+		{"my agent", "invalid package name"},
+		{".hidden", "invalid package name"},
+		{"node_modules", "is reserved"},
+		{"@acme/con", "is reserved"},
+		{"a" + string(make([]byte, MaxNameLength)), "characters long"},
+	}
+
+	for _, tt := range tests {
+		c := &checker{}
+		checkPackageName(c, []string{"name"}, tt.name, false)
+		if tt.err == "" {
+			assert.Empty(t, c.problems, tt.name)
+			continue
+		}
+		require.Len(t, c.problems, 1, tt.name)
+		assert.Contains(t, c.problems[0].Message, tt.err)
+	}
+}
+
+func TestCheckDependencies(t *testing.T) {
+	problems := CheckAgentPkg(&AgentPkg{
+		Name:    "agent",
+		Version: "1.0.0",
+		License: "MIT",
+		Dependencies: map[string]string{
+			"agent": "*",
+			"empty": "",
+			"ok":    "^1.2.0 || ~2.0",
+			"range": ">=1.0 <",
+		},
+	})
+
+	var messages []string
+	for _, p := range problems {
+		messages = append(messages, p.String())
+	}
+	require.Len(t, problems, 4, messages)
+	assert.Contains(t, messages[1], "error: dependencies.agent: a package cannot depend on itself")
+	assert.Contains(t, messages[2], "warning: dependencies.empty: an empty constraint")
+	assert.Contains(t, messages[3], "error: dependencies.range: ")
+}
+
+func TestCheckDevDependencies(t *testing.T) {
+	problems := CheckAgentPkg(&AgentPkg{
+		Name:            "agent",
+		Version:         "1.0.0",
+		Description:     "An agent",
+		License:         "MIT",
+		Dependencies:    map[string]string{"web-search": "^2.0.0"},
+		DevDependencies: map[string]string{"web-search": "^2.0.0", "eval-kit": "0.x", "Bad": "*"},
+	})
+
+	var messages []string
+	for _, p := range problems {
+		messages = append(messages, p.String())
+	}
+	require.Len(t, problems, 2, messages)
+	assert.Contains(t, messages[0], "error: devDependencies.Bad: name \"Bad\" must be lowercase")
+	assert.Contains(t, messages[1], "error: devDependencies.web-search: web-search is also listed in dependencies")
+}
+
+func TestCheckAuthor(t *testing.T) {
+	tests := []struct {
+		author   string
+		severity Severity
+		err      string
+	}{
+		{"Jane Doe", "", ""},
+		{"Jane Doe <jane@example.com> (https://example.com)", "", ""},
+		{"Jane Doe (https://example.com)", "", ""},
+		{"<jane@example.com>", SeverityWarning, "author has no name"},
+		{"Jane <jane@>", SeverityError, "invalid email address"},
+		{"Jane (example.com)", SeverityError, "invalid URL"},
+		{"Jane <jane@example.com> <again>", SeverityError, "must be formatted"},
+	}
+
+	for _, tt := range tests {
+		c := &checker{}
+		checkAuthor(c, tt.author)
+		if tt.err == "" {
+			assert.Empty(t, c.problems, tt.author)
+			continue
+		}
+		require.Len(t, c.problems, 1, tt.author)
+		assert.Equal(t, tt.severity, c.problems[0].Severity, tt.author)
+		assert.Contains(t, c.problems[0].Message, tt.err)
+	}
+}